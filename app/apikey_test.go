@@ -0,0 +1,53 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAPIKey(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin()
+	s.signup("bob@example.com", "0812345678")
+	bob := s.login("bob@example.com")
+	bobToken := bob["token"].(string)
+	bobPath := "/user/" + bob["user_id"].(string)
+
+	// scope tidak boleh melebihi permission pemilik key
+	s.expect(http.StatusBadRequest, "POST", "/user/apikeys", bobToken, map[string]interface{}{"name": "ci", "scopes": []string{"users:everything"}})
+	s.expect(http.StatusForbidden, "POST", "/user/apikeys", bobToken, map[string]interface{}{"name": "ci", "scopes": []string{"users:read"}})
+
+	created := s.expect(http.StatusCreated, "POST", "/user/apikeys", bobToken, map[string]interface{}{"name": "ci", "scopes": []string{}})
+	apiKey := "ApiKey " + created["api_key"].(string)
+	keyId := created["key"].(map[string]interface{})["key_id"].(string)
+
+	// key tidak pernah ditampilkan lagi setelah dibuat
+	listed := s.expect(http.StatusOK, "GET", "/user/apikeys", bobToken, nil)
+	if total := listed["total_count"]; total != float64(1) {
+		t.Errorf("total_count = %v, want 1", total)
+	}
+	for field, value := range listed["keys"].([]interface{})[0].(map[string]interface{}) {
+		if value == created["api_key"] {
+			t.Errorf("key list shows the full API key in %s", field)
+		}
+	}
+
+	// API key hanya boleh membaca akunnya sendiri dan tidak bisa memakai route sesi
+	s.expect(http.StatusOK, "GET", bobPath, apiKey, nil)
+	s.expect(http.StatusForbidden, "PATCH", bobPath, apiKey, map[string]string{"first_name": "Robert"})
+	s.expect(http.StatusForbidden, "GET", "/user/apikeys", apiKey, nil)
+	s.expect(http.StatusForbidden, "POST", "/user/logout", apiKey, nil)
+
+	// permission admin hanya ikut sejauh scope key-nya
+	unscoped := s.expect(http.StatusCreated, "POST", "/user/apikeys", adminToken, map[string]interface{}{"name": "ops", "scopes": []string{}})
+	scoped := s.expect(http.StatusCreated, "POST", "/user/apikeys", adminToken, map[string]interface{}{"name": "ops", "scopes": []string{"users:read"}})
+	s.expect(http.StatusForbidden, "GET", "/users", "ApiKey "+unscoped["api_key"].(string), nil)
+	s.expect(http.StatusOK, "GET", "/users", "ApiKey "+scoped["api_key"].(string), nil)
+	s.expect(http.StatusForbidden, "DELETE", bobPath, "ApiKey "+scoped["api_key"].(string), nil)
+
+	// key yang dicabut langsung ditolak, dan hanya pemiliknya yang bisa mencabut
+	s.expect(http.StatusNotFound, "DELETE", "/user/apikeys/"+keyId, adminToken, nil)
+	s.expect(http.StatusOK, "DELETE", "/user/apikeys/"+keyId, bobToken, nil)
+	s.expect(http.StatusUnauthorized, "GET", bobPath, apiKey, nil)
+	s.expect(http.StatusUnauthorized, "GET", bobPath, "ApiKey not-a-key", nil)
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"golangsidang/config"
+	"golangsidang/database"
+	helper "golangsidang/helpers"
+	"golangsidang/keystore"
+	"golangsidang/lockout"
+	"golangsidang/mailer"
+	"golangsidang/middleware"
+	"golangsidang/models"
+	"golangsidang/passhash"
+	"golangsidang/phone"
+	"golangsidang/ratelimit"
+	"golangsidang/repository"
+	"golangsidang/revocation"
+	routes "golangsidang/routes"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Dependencies adalah penyimpanan dan logger yang dipakai App.
+// Gunakan MongoDependencies atau MemoryDependencies, atau isi sendiri (misalnya untuk pengujian).
+type Dependencies struct {
+	Users           repository.UserRepository
+	Roles           repository.RoleRepository
+	Organizations   repository.OrganizationRepository
+	Invitations     repository.InvitationRepository
+	APIKeys         repository.APIKeyRepository
+	Sessions        repository.SessionRepository
+	SigningKeys     *keystore.Store
+	PasetoKeys      *keystore.Store
+	PasetoLocalKeys *keystore.Store
+	Revocations     revocation.Store
+	UserTokens      repository.UserTokenRepository
+	Mailer          mailer.Mailer
+	SMS             phone.SMSSender
+	LoginAttempts   lockout.Store
+	RateLimits      ratelimit.Store
+	Logger          *log.Logger
+}
+
+// App adalah service yang sudah dirakit dan siap dijalankan atau dipasang di http.Server lain
+type App struct {
+	config config.Config
+	deps   Dependencies
+	// services dipakai juga di luar request, misalnya InviteBootstrapAdmin
+	services *helper.Services
+	router   *gin.Engine
+}
+
+// MongoDependencies membuat semua penyimpanan di atas client MongoDB yang sudah terhubung
+func MongoDependencies(client *mongo.Client, cfg config.Config, logger *log.Logger) Dependencies {
+	grace := gracePeriod(cfg)
+	return Dependencies{
+		Users:           repository.NewMongoUserRepository(database.OpenCollection(client, "user"), database.OpenCollection(client, "organizations")),
+		Roles:           repository.NewMongoRoleRepository(database.OpenCollection(client, "roles")),
+		Organizations:   repository.NewMongoOrganizationRepository(database.OpenCollection(client, "organizations")),
+		Invitations:     repository.NewMongoInvitationRepository(database.OpenCollection(client, "invitations")),
+		APIKeys:         repository.NewMongoAPIKeyRepository(database.OpenCollection(client, "api_keys")),
+		Sessions:        repository.NewMongoSessionRepository(database.OpenCollection(client, "session")),
+		SigningKeys:     keystore.New(keystore.NewMongoRepository(database.OpenCollection(client, "signing_keys")), keystore.AlgorithmHS256, grace, []byte(cfg.SecretKey)),
+		PasetoKeys:      keystore.New(keystore.NewMongoRepository(database.OpenCollection(client, "paseto_keys")), keystore.AlgorithmEd25519, grace, nil),
+		PasetoLocalKeys: keystore.New(keystore.NewMongoRepository(database.OpenCollection(client, "paseto_local_keys")), keystore.AlgorithmXChaCha20Poly1305, grace, nil),
+		Revocations:     revocation.NewMongoStore(database.OpenCollection(client, "revoked_tokens")),
+		UserTokens:      repository.NewMongoUserTokenRepository(database.OpenCollection(client, "user_tokens")),
+		Mailer:          newMailer(cfg),
+		SMS:             phone.NewLogSender(logger),
+		LoginAttempts:   lockout.NewMongoStore(database.OpenCollection(client, "login_attempts")),
+		RateLimits:      ratelimit.NewMongoStore(database.OpenCollection(client, "rate_limits")),
+		Logger:          logger,
+	}
+}
+
+// MemoryDependencies membuat semua penyimpanan di memori, sehingga App bisa jalan tanpa MongoDB
+func MemoryDependencies(cfg config.Config, logger *log.Logger) Dependencies {
+	grace := gracePeriod(cfg)
+	return Dependencies{
+		Users:           repository.NewMemoryUserRepository(),
+		Roles:           repository.NewMemoryRoleRepository(models.DefaultRoles()...),
+		Organizations:   repository.NewMemoryOrganizationRepository(),
+		Invitations:     repository.NewMemoryInvitationRepository(),
+		APIKeys:         repository.NewMemoryAPIKeyRepository(),
+		Sessions:        repository.NewMemorySessionRepository(),
+		SigningKeys:     keystore.New(keystore.NewMemoryRepository(), keystore.AlgorithmHS256, grace, []byte(cfg.SecretKey)),
+		PasetoKeys:      keystore.New(keystore.NewMemoryRepository(), keystore.AlgorithmEd25519, grace, nil),
+		PasetoLocalKeys: keystore.New(keystore.NewMemoryRepository(), keystore.AlgorithmXChaCha20Poly1305, grace, nil),
+		Revocations:     revocation.NewMemoryStore(),
+		UserTokens:      repository.NewMemoryUserTokenRepository(),
+		Mailer:          newMailer(cfg),
+		SMS:             phone.NewLogSender(logger),
+		LoginAttempts:   lockout.NewMemoryStore(),
+		RateLimits:      ratelimit.NewMemoryStore(),
+		Logger:          logger,
+	}
+}
+
+// New merakit router gin dari routes.AuthRoutes dan routes.UserRoutes.
+// Kesalahan konfigurasi dikembalikan sebagai error, bukan menghentikan proses.
+func New(cfg config.Config, deps Dependencies) (*App, error) {
+	if err := deps.validate(); err != nil {
+		return nil, err
+	}
+	policies, err := ratelimit.ParsePolicies(cfg.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+	limiter := middleware.NewRateLimiter(deps.RateLimits, policies)
+	hasher, err := cfg.PasswordHasher()
+	if err != nil {
+		return nil, err
+	}
+	passwordPolicy, err := cfg.PasswordPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	// helpers memakai penyimpanan yang sama untuk menerbitkan dan memverifikasi token
+	services := &helper.Services{
+		SigningKeys:     deps.SigningKeys,
+		PasetoKeys:      deps.PasetoKeys,
+		PasetoLocalKeys: deps.PasetoLocalKeys,
+		Roles:           deps.Roles,
+		Organizations:   deps.Organizations,
+		Invitations:     deps.Invitations,
+		APIKeys:         deps.APIKeys,
+		Sessions:        deps.Sessions,
+		UserTokens:      deps.UserTokens,
+		Revocations:     deps.Revocations,
+		Passwords:       passhash.New(hasher),
+		PasswordPolicy:  passwordPolicy,
+		LoginAttempts:   lockout.NewGuard(deps.LoginAttempts, lockout.DefaultEmailPolicy, lockout.DefaultIPPolicy),
+	}
+	verifiers, err := services.TokenVerifiers(cfg.TokenFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.New() // membuat router baru
+	// tanpa proxy yang dipercaya, ClientIP memakai alamat koneksi sehingga X-Forwarded-For tidak bisa dipalsukan
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		return nil, err
+	}
+	router.Use(gin.LoggerWithWriter(deps.Logger.Writer()))                                                                                         // menggunakan logger
+	routes.AuthRoutes(router, deps.Users, services, deps.Mailer, limiter, cfg)                                                                     // menggunakan routes auth
+	routes.UserRoutes(router, deps.Users, services, middleware.Authenticate(verifiers, deps.Users, services), deps.SMS, deps.Mailer, limiter, cfg) // menggunakan routes user
+
+	router.GET("/api-1", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "Hello World",
+		}) // membuat api di passing menjadi berupa json dan memunculkan hello world
+	})
+	router.GET("/api-2", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "Hello World"}) // membuat api di passing menjadi berupa json dan memunculkan hello world
+	})
+
+	return &App{config: cfg, deps: deps, services: services, router: router}, nil
+}
+
+// Handler mengembalikan router untuk dipasang di http.Server atau httptest
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Run menjalankan server di port dari Config, beserta purger akun yang sudah dihapus.
+// Undangan admin pertama (BootstrapAdminEmail) dikirim lebih dulu jika perlu.
+func (a *App) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.InviteBootstrapAdmin(ctx); err != nil {
+		a.deps.Logger.Printf("Error inviting bootstrap admin: %v", err)
+	}
+	go a.RunPurger(ctx, purgeInterval)
+	return a.router.Run(":" + a.config.Port)
+}
+
+func (d Dependencies) validate() error {
+	switch {
+	case d.Users == nil:
+		return errors.New("app: missing user repository")
+	case d.Roles == nil:
+		return errors.New("app: missing role repository")
+	case d.Organizations == nil:
+		return errors.New("app: missing organization repository")
+	case d.Invitations == nil:
+		return errors.New("app: missing invitation repository")
+	case d.APIKeys == nil:
+		return errors.New("app: missing API key repository")
+	case d.Sessions == nil:
+		return errors.New("app: missing session repository")
+	case d.SigningKeys == nil || d.PasetoKeys == nil || d.PasetoLocalKeys == nil:
+		return errors.New("app: missing key store")
+	case d.Revocations == nil:
+		return errors.New("app: missing revocation store")
+	case d.UserTokens == nil:
+		return errors.New("app: missing user token repository")
+	case d.Mailer == nil:
+		return errors.New("app: missing mailer")
+	case d.SMS == nil:
+		return errors.New("app: missing SMS sender")
+	case d.LoginAttempts == nil:
+		return errors.New("app: missing login attempt store")
+	case d.RateLimits == nil:
+		return errors.New("app: missing rate limit store")
+	case d.Logger == nil:
+		return errors.New("app: missing logger")
+	}
+	return nil
+}
+
+func gracePeriod(cfg config.Config) time.Duration {
+	if cfg.KeyGracePeriod <= 0 {
+		return helper.RefreshTokenTTL
+	}
+	return cfg.KeyGracePeriod
+}
+
+// newMailer memilih pengirim email sesuai MailDriver (sudah divalidasi oleh config.Validate)
+func newMailer(cfg config.Config) mailer.Mailer {
+	switch cfg.MailDriver {
+	case mailer.DriverSMTP:
+		return mailer.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	case mailer.DriverMemory:
+		return mailer.NewMemoryMailer()
+	default:
+		return mailer.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+	}
+}
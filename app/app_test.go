@@ -0,0 +1,184 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"golangsidang/app"
+	"golangsidang/config"
+	"golangsidang/mailer"
+	"golangsidang/passhash"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "Secret-pass1"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testServer adalah App lengkap di atas MemoryDependencies, dipakai lewat ServeHTTP tanpa membuka port
+type testServer struct {
+	t    *testing.T
+	app  *app.App
+	mail *mailer.MemoryMailer
+}
+
+// newTestServer merakit App; configure (opsional) mengubah konfigurasi bawaan test sebelum app.New
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.MailDriver = mailer.DriverMemory
+	cfg.InvitationURL = "http://front/invite"
+	cfg.BootstrapAdminEmail = "root@example.com"
+	// bcrypt dengan cost minimal supaya test tidak lambat karena hash password
+	cfg.PasswordHash = passhash.AlgorithmBcrypt
+	cfg.BcryptCost = bcrypt.MinCost
+	for _, f := range configure {
+		f(&cfg)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	deps := app.MemoryDependencies(cfg, logger)
+	mail := mailer.NewMemoryMailer()
+	deps.Mailer = mail
+	a, err := app.New(cfg, deps)
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	return &testServer{t: t, app: a, mail: mail}
+}
+
+// do mengirim request JSON dan mengembalikan status beserta body yang sudah di-decode
+func (s *testServer) do(method string, path string, token string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	rec := httptest.NewRecorder()
+	s.app.Handler().ServeHTTP(rec, req)
+
+	var out map[string]interface{}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			s.t.Fatalf("%s %s: decode response %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code, out
+}
+
+// expect gagal jika status tidak sesuai, lalu mengembalikan body respons
+func (s *testServer) expect(want int, method string, path string, token string, body interface{}) map[string]interface{} {
+	s.t.Helper()
+	got, out := s.do(method, path, token, body)
+	if got != want {
+		s.t.Fatalf("%s %s: status %d, want %d (body %v)", method, path, got, want, out)
+	}
+	return out
+}
+
+func (s *testServer) signup(email string, phone string) {
+	s.t.Helper()
+	s.expect(http.StatusOK, "POST", "/user/signup", "", map[string]string{
+		"first_name": "Test", "last_name": "User", "email": email, "phone": phone, "password": testPassword, "user_type": "USER",
+	})
+}
+
+// login mengembalikan respons login (user beserta token, refresh_token dan user_id)
+func (s *testServer) login(email string) map[string]interface{} {
+	s.t.Helper()
+	return s.expect(http.StatusOK, "POST", "/user/login", "", map[string]string{"email": email, "password": testPassword})
+}
+
+// admin membuat admin pertama lewat undangan bootstrap lalu mengembalikan access token-nya
+func (s *testServer) admin() string {
+	s.t.Helper()
+	if err := s.app.InviteBootstrapAdmin(context.Background()); err != nil {
+		s.t.Fatalf("InviteBootstrapAdmin: %v", err)
+	}
+	s.expect(http.StatusOK, "POST", "/user/signup", "", map[string]string{
+		"first_name": "Root", "last_name": "Admin", "email": "root@example.com", "phone": "0812000000", "password": testPassword,
+		"invitation_token": s.lastMailToken(),
+	})
+	return s.login("root@example.com")["token"].(string)
+}
+
+// lastMailToken mengambil parameter token dari link di email terakhir
+func (s *testServer) lastMailToken() string {
+	s.t.Helper()
+	messages := s.mail.Messages()
+	if len(messages) == 0 {
+		s.t.Fatal("no email was sent")
+	}
+	body := messages[len(messages)-1].Body
+	i := strings.Index(body, "token=")
+	if i < 0 {
+		s.t.Fatalf("no token in email %q", body)
+	}
+	token := body[i+len("token="):]
+	if end := strings.IndexAny(token, "\r\n"); end >= 0 {
+		token = token[:end]
+	}
+	token, err := url.QueryUnescape(token)
+	if err != nil {
+		s.t.Fatalf("unescape token: %v", err)
+	}
+	return token
+}
+
+func TestSignupAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("bob@example.com", "0812345678")
+
+	// email yang sama tidak boleh dipakai dua kali
+	s.expect(http.StatusConflict, "POST", "/user/signup", "", map[string]string{
+		"first_name": "Bob", "last_name": "Again", "email": "bob@example.com", "phone": "0812345679", "password": testPassword, "user_type": "USER",
+	})
+	// signup terbuka tidak boleh memilih role selain USER
+	s.expect(http.StatusForbidden, "POST", "/user/signup", "", map[string]string{
+		"first_name": "Eve", "last_name": "Admin", "email": "eve@example.com", "phone": "0812345670", "password": testPassword, "user_type": "ADMIN",
+	})
+
+	s.expect(http.StatusUnauthorized, "POST", "/user/login", "", map[string]string{"email": "bob@example.com", "password": "wrong-password"})
+	user := s.login("bob@example.com")
+	for _, field := range []string{"token", "refresh_token", "paseto_token", "public_paseto_token"} {
+		if value, _ := user[field].(string); value == "" {
+			t.Errorf("login response has no %s", field)
+		}
+	}
+	if _, ok := user["password"]; ok {
+		t.Error("login response contains the password hash")
+	}
+
+	profile := s.expect(http.StatusOK, "GET", "/user/"+user["user_id"].(string), user["token"].(string), nil)
+	if profile["email"] != "bob@example.com" {
+		t.Errorf("profile email = %v, want bob@example.com", profile["email"])
+	}
+	if _, ok := profile["token"]; ok {
+		t.Error("profile contains a session token")
+	}
+	s.expect(http.StatusUnauthorized, "GET", "/user/"+user["user_id"].(string), "Bearer not-a-token", nil)
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"errors"
+	controller "golangsidang/controllers"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InviteBootstrapAdmin mengundang BootstrapAdminEmail sebagai ADMIN. Signup terbuka tidak bisa memberi role ADMIN,
+// jadi ini cara membuat admin pertama. Tidak ada yang dikirim jika email sudah terdaftar atau masih punya undangan ADMIN yang berlaku.
+func (a *App) InviteBootstrapAdmin(ctx context.Context) error {
+	email := a.config.BootstrapAdminEmail
+	if email == "" {
+		return nil
+	}
+	_, err := a.deps.Users.FindByEmail(ctx, email)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	pending, _, err := a.deps.Invitations.List(ctx, models.InvitationPending, now, 0, 100)
+	if err != nil {
+		return err
+	}
+	for _, invitation := range pending {
+		if strings.EqualFold(invitation.Email, email) && invitation.Role == models.RoleAdmin {
+			return nil
+		}
+	}
+
+	invitation := models.Invitation{
+		Invitation_id: primitive.NewObjectID().Hex(),
+		Email:         email,
+		Role:          models.RoleAdmin,
+		Created_at:    now,
+		Expires_at:    now.Add(a.config.InvitationTTL),
+	}
+	if _, err := controller.SendInvitation(ctx, a.services, a.deps.Mailer, a.config.InvitationURL, &invitation, models.Organization{}); err != nil {
+		return err
+	}
+	a.deps.Logger.Printf("Invited bootstrap admin %s (invitation %s)", email, invitation.Invitation_id)
+	return nil
+}
@@ -0,0 +1,56 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestInvitation(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin()
+	s.signup("bob@example.com", "0812345678")
+	bob := s.login("bob@example.com")["token"].(string)
+
+	// mengundang butuh users:invite
+	s.expect(http.StatusForbidden, "POST", "/invitations", bob, map[string]string{"email": "new@example.com"})
+	s.expect(http.StatusConflict, "POST", "/invitations", adminToken, map[string]string{"email": "bob@example.com"})
+
+	// admin hanya boleh mengundang ke organisasi yang dia kelola
+	orgId := s.expect(http.StatusCreated, "POST", "/orgs", bob, map[string]string{"name": "Acme"})["org_id"].(string)
+	s.expect(http.StatusForbidden, "POST", "/invitations", adminToken, map[string]string{"email": "new@example.com", "org_id": orgId})
+
+	// undangan hanya berlaku untuk email tujuan dan hanya sekali
+	created := s.expect(http.StatusCreated, "POST", "/invitations", adminToken, map[string]string{"email": "new@example.com", "role": "ADMIN"})
+	if status := created["invitation"].(map[string]interface{})["status"]; status != "pending" {
+		t.Errorf("status = %v, want pending", status)
+	}
+	token := s.lastMailToken()
+	signup := map[string]string{
+		"first_name": "New", "last_name": "Admin", "email": "other@example.com", "phone": "0812345679", "password": testPassword,
+		"invitation_token": token,
+	}
+	s.expect(http.StatusBadRequest, "POST", "/user/signup", "", signup)
+	signup["email"] = "new@example.com"
+	s.expect(http.StatusOK, "POST", "/user/signup", "", signup)
+	signup["email"], signup["phone"] = "again@example.com", "0812345670"
+	s.expect(http.StatusBadRequest, "POST", "/user/signup", "", signup)
+
+	// role dari undangan ikut terpasang
+	s.expect(http.StatusOK, "GET", "/users", s.login("new@example.com")["token"].(string), nil)
+
+	// undangan yang dicabut tidak bisa dipakai
+	revoked := s.expect(http.StatusCreated, "POST", "/invitations", adminToken, map[string]string{"email": "late@example.com"})
+	invitationId := revoked["invitation"].(map[string]interface{})["invitation_id"].(string)
+	token = s.lastMailToken()
+	s.expect(http.StatusOK, "DELETE", "/invitations/"+invitationId, adminToken, nil)
+	s.expect(http.StatusConflict, "DELETE", "/invitations/"+invitationId, adminToken, nil)
+	s.expect(http.StatusBadRequest, "POST", "/user/signup", "", map[string]string{
+		"first_name": "Late", "last_name": "User", "email": "late@example.com", "phone": "0812345677", "password": testPassword,
+		"invitation_token": token,
+	})
+
+	list := s.expect(http.StatusOK, "GET", "/invitations?status=revoked", adminToken, nil)
+	if total := list["total_count"]; total != float64(1) {
+		t.Errorf("revoked total_count = %v, want 1", total)
+	}
+}
@@ -0,0 +1,44 @@
+package app_test
+
+import (
+	"golangsidang/config"
+	"golangsidang/totp"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLockoutAcrossMFA(t *testing.T) {
+	// rate limit login dilonggarkan supaya yang diuji hanya lockout per email
+	s := newTestServer(t, func(cfg *config.Config) { cfg.RateLimits = "login=1000/1m" })
+	s.signup("bob@example.com", "0812345678")
+	token := s.login("bob@example.com")["token"].(string)
+
+	enrollment := s.expect(http.StatusOK, "POST", "/user/mfa/totp/enroll", token, nil)
+	code, err := totp.Code(enrollment["secret"].(string), totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("totp.Code: %v", err)
+	}
+	s.expect(http.StatusOK, "POST", "/user/mfa/totp/confirm", token, map[string]string{"code": code})
+
+	// password yang benar di antara kode MFA yang salah tidak boleh menghapus hitungan gagal
+	for i := 0; ; i++ {
+		status, body := s.do("POST", "/user/login", "", map[string]string{"email": "bob@example.com", "password": testPassword})
+		if status == http.StatusTooManyRequests {
+			break
+		}
+		if status != http.StatusOK || body["mfa_token"] == nil {
+			t.Fatalf("login: status %d, body %v", status, body)
+		}
+		if i == 10 {
+			t.Fatal("email was not locked after repeated MFA failures")
+		}
+		status, body = s.do("POST", "/user/login/mfa", "", map[string]string{"mfa_token": body["mfa_token"].(string), "code": "000000"})
+		if status == http.StatusTooManyRequests {
+			break
+		}
+		if status != http.StatusUnauthorized {
+			t.Fatalf("login/mfa: status %d, body %v", status, body)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTenantScoping(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("owner@example.com", "0812345671")
+	s.signup("member@example.com", "0812345672")
+	s.signup("outsider@example.com", "0812345673")
+	owner := s.login("owner@example.com")["token"].(string)
+	outsiderLogin := s.login("outsider@example.com")
+	outsider := outsiderLogin["token"].(string)
+
+	org := s.expect(http.StatusCreated, "POST", "/orgs", owner, map[string]string{"name": "Acme"})
+	orgId := org["org_id"].(string)
+
+	// token lama belum berada di organisasi, jadi route /org ditolak sampai pindah organisasi
+	s.expect(http.StatusForbidden, "GET", "/org/members", owner, nil)
+	owner = s.expect(http.StatusOK, "POST", "/orgs/"+orgId+"/switch", owner, nil)["token"].(string)
+
+	s.expect(http.StatusBadRequest, "POST", "/org/members", owner, map[string]string{"email": "member@example.com", "role": "ADMIN"})
+	s.expect(http.StatusCreated, "POST", "/org/members", owner, map[string]string{"email": "member@example.com", "role": "ORG_MEMBER"})
+	members := s.expect(http.StatusOK, "GET", "/org/members", owner, nil)
+	if total := members["total_count"]; total != float64(2) {
+		t.Errorf("total_count = %v, want 2", total)
+	}
+
+	// anggota biasa boleh melihat tetapi tidak boleh mengelola anggota
+	member := s.login("member@example.com")["token"].(string)
+	s.expect(http.StatusOK, "GET", "/org/members", member, nil)
+	s.expect(http.StatusForbidden, "POST", "/org/members", member, map[string]string{"email": "outsider@example.com", "role": "ORG_MEMBER"})
+
+	// bukan anggota tidak bisa masuk ke organisasi, dan tidak terlihat dari dalam organisasi
+	s.expect(http.StatusForbidden, "POST", "/orgs/"+orgId+"/switch", outsider, nil)
+	s.expect(http.StatusForbidden, "GET", "/org/members", outsider, nil)
+	s.expect(http.StatusNotFound, "PATCH", "/org/members/"+outsiderLogin["user_id"].(string), owner, map[string]string{"role": "ORG_ADMIN"})
+	s.expect(http.StatusNotFound, "DELETE", "/org/members/"+outsiderLogin["user_id"].(string), owner, nil)
+}
+
+func TestOrganizationKeepsAdmin(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("owner@example.com", "0812345671")
+	s.signup("member@example.com", "0812345672")
+	ownerLogin := s.login("owner@example.com")
+	ownerPath := "/org/members/" + ownerLogin["user_id"].(string)
+	orgId := s.expect(http.StatusCreated, "POST", "/orgs", ownerLogin["token"].(string), map[string]string{"name": "Acme"})["org_id"].(string)
+	owner := s.expect(http.StatusOK, "POST", "/orgs/"+orgId+"/switch", ownerLogin["token"].(string), nil)["token"].(string)
+
+	// ORG_ADMIN terakhir tidak boleh diturunkan atau dikeluarkan
+	s.expect(http.StatusBadRequest, "PATCH", ownerPath, owner, map[string]string{"role": "ORG_MEMBER"})
+	s.expect(http.StatusBadRequest, "DELETE", ownerPath, owner, nil)
+
+	// setelah ada admin lain, admin pertama boleh turun
+	added := s.expect(http.StatusCreated, "POST", "/org/members", owner, map[string]string{"email": "member@example.com", "role": "ORG_ADMIN"})
+	s.expect(http.StatusOK, "PATCH", ownerPath, owner, map[string]string{"role": "ORG_MEMBER"})
+
+	// sesi anggota yang role-nya berubah diakhiri, jadi admin baru masuk ulang ke organisasi
+	member := s.login("member@example.com")["token"].(string)
+	member = s.expect(http.StatusOK, "POST", "/orgs/"+orgId+"/switch", member, nil)["token"].(string)
+	s.expect(http.StatusBadRequest, "DELETE", "/org/members/"+added["user_id"].(string), member, nil)
+	s.expect(http.StatusOK, "DELETE", ownerPath, member, nil)
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// purgeInterval adalah jeda antar pemeriksaan akun yang masa tenggangnya sudah lewat
+const purgeInterval = time.Hour
+
+// PurgeDeletedUsers menghapus permanen akun yang di-soft-delete lebih lama dari DeletionGracePeriod
+func (a *App) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	return a.deps.Users.PurgeDeleted(ctx, time.Now().Add(-a.config.DeletionGracePeriod))
+}
+
+// RunPurger menjalankan PurgeDeletedUsers setiap interval sampai ctx dibatalkan
+func (a *App) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgeCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		purged, err := a.PurgeDeletedUsers(purgeCtx)
+		cancel()
+		if err != nil {
+			a.deps.Logger.Printf("Error purging deleted users: %v", err)
+		} else if purged > 0 {
+			a.deps.Logger.Printf("Purged %d deleted users", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
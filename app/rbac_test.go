@@ -0,0 +1,40 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRBAC(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin()
+	s.signup("bob@example.com", "0812345678")
+	s.signup("carol@example.com", "0812345679")
+	bob := s.login("bob@example.com")
+	carol := s.login("carol@example.com")
+	bobToken := bob["token"].(string)
+	bobPath := "/user/" + bob["user_id"].(string)
+	carolPath := "/user/" + carol["user_id"].(string)
+
+	s.expect(http.StatusForbidden, "GET", "/users", bobToken, nil)
+	s.expect(http.StatusOK, "GET", "/users", adminToken, nil)
+
+	// user biasa hanya boleh mengakses akunnya sendiri
+	s.expect(http.StatusOK, "GET", bobPath, bobToken, nil)
+	s.expect(http.StatusForbidden, "GET", carolPath, bobToken, nil)
+	s.expect(http.StatusForbidden, "PATCH", carolPath, bobToken, map[string]string{"first_name": "Mallory"})
+	s.expect(http.StatusForbidden, "DELETE", carolPath, bobToken, nil)
+	s.expect(http.StatusForbidden, "POST", carolPath+"/revoke", bobToken, nil)
+	s.expect(http.StatusForbidden, "GET", "/roles", bobToken, nil)
+
+	// mengganti role sendiri butuh roles:assign
+	s.expect(http.StatusForbidden, "PATCH", bobPath, bobToken, map[string]string{"user_type": "ADMIN"})
+	s.expect(http.StatusOK, "PATCH", bobPath, bobToken, map[string]string{"first_name": "Robert"})
+
+	// admin boleh mengelola user lain
+	s.expect(http.StatusOK, "GET", carolPath, adminToken, nil)
+	updated := s.expect(http.StatusOK, "PATCH", carolPath, adminToken, map[string]string{"user_type": "ADMIN"})
+	if updated["user_type"] != "ADMIN" {
+		t.Errorf("user_type = %v, want ADMIN", updated["user_type"])
+	}
+}
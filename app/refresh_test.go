@@ -0,0 +1,34 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("bob@example.com", "0812345678")
+	first := s.login("bob@example.com")["refresh_token"].(string)
+
+	rotated := s.expect(http.StatusOK, "POST", "/user/refresh", "", map[string]string{"refresh_token": first})
+	second := rotated["refresh_token"].(string)
+	if second == first {
+		t.Fatal("refresh token was not rotated")
+	}
+	// refresh menerbitkan semua format access token seperti login, dan semuanya diterima middleware
+	for _, field := range []string{"token", "paseto_token", "public_paseto_token"} {
+		token, _ := rotated[field].(string)
+		if token == "" {
+			t.Fatalf("refresh response has no %s", field)
+		}
+		s.expect(http.StatusOK, "GET", "/user/sessions", "Bearer "+token, nil)
+	}
+
+	// refresh token lama yang dipakai lagi mengakhiri sesi, termasuk refresh token hasil rotasi
+	s.expect(http.StatusUnauthorized, "POST", "/user/refresh", "", map[string]string{"refresh_token": first})
+	s.expect(http.StatusUnauthorized, "POST", "/user/refresh", "", map[string]string{"refresh_token": second})
+
+	// access token tidak diterima sebagai refresh token
+	access := s.login("bob@example.com")["token"].(string)
+	s.expect(http.StatusUnauthorized, "POST", "/user/refresh", "", map[string]string{"refresh_token": access})
+}
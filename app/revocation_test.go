@@ -0,0 +1,33 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRevocation(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin()
+	s.signup("bob@example.com", "0812345678")
+
+	// logout mencabut access token dan refresh token sesi tersebut
+	session := s.login("bob@example.com")
+	userPath := "/user/" + session["user_id"].(string)
+	s.expect(http.StatusOK, "POST", "/user/logout", session["token"].(string), nil)
+	s.expect(http.StatusUnauthorized, "GET", userPath, session["token"].(string), nil)
+	s.expect(http.StatusUnauthorized, "POST", "/user/refresh", "", map[string]string{"refresh_token": session["refresh_token"].(string)})
+
+	// admin mencabut semua sesi dan API key user
+	session = s.login("bob@example.com")
+	key := s.expect(http.StatusCreated, "POST", "/user/apikeys", session["token"].(string), map[string]interface{}{"name": "ci", "scopes": []string{}})
+	apiKey := "ApiKey " + key["api_key"].(string)
+	s.expect(http.StatusOK, "GET", userPath, apiKey, nil)
+
+	s.expect(http.StatusOK, "POST", userPath+"/revoke", adminToken, nil)
+	s.expect(http.StatusUnauthorized, "GET", userPath, session["token"].(string), nil)
+	s.expect(http.StatusUnauthorized, "POST", "/user/refresh", "", map[string]string{"refresh_token": session["refresh_token"].(string)})
+	s.expect(http.StatusUnauthorized, "GET", userPath, apiKey, nil)
+
+	// setelah dicabut, user masih bisa login lagi
+	s.expect(http.StatusOK, "GET", userPath, s.login("bob@example.com")["token"].(string), nil)
+}
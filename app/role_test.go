@@ -0,0 +1,30 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRolePermissionsLimitedToCaller(t *testing.T) {
+	s := newTestServer(t)
+	adminToken := s.admin()
+	s.signup("bob@example.com", "0812345678")
+	bob := s.login("bob@example.com")
+
+	// EDITOR boleh mengelola role tetapi tidak punya permission users:*
+	s.expect(http.StatusCreated, "POST", "/roles", adminToken, map[string]interface{}{
+		"name": "EDITOR", "permissions": []string{"roles:read", "roles:write"},
+	})
+	s.expect(http.StatusOK, "PATCH", "/user/"+bob["user_id"].(string), adminToken, map[string]string{"user_type": "EDITOR"})
+	editor := s.login("bob@example.com")["token"].(string)
+
+	s.expect(http.StatusForbidden, "POST", "/roles", editor, map[string]interface{}{
+		"name": "DELETER", "permissions": []string{"users:delete"},
+	})
+	s.expect(http.StatusForbidden, "PUT", "/roles/EDITOR", editor, map[string]interface{}{
+		"permissions": []string{"roles:read", "roles:write", "users:delete"},
+	})
+	s.expect(http.StatusCreated, "POST", "/roles", editor, map[string]interface{}{
+		"name": "VIEWER", "permissions": []string{"roles:read"},
+	})
+}
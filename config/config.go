@@ -0,0 +1,460 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golangsidang/mailer"
+	"golangsidang/passhash"
+	"golangsidang/passpolicy"
+	"golangsidang/ratelimit"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Storage yang didukung
+const (
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+)
+
+// MinSecretKeyLength adalah panjang minimal SECRET_KEY (HS256 butuh kunci minimal 256 bit)
+const MinSecretKeyLength = 32
+
+// placeholderSecrets adalah nilai contoh yang tidak boleh dipakai sebagai secret sungguhan
+var placeholderSecrets = []string{
+	"your_secret_key_here", "your-secret-key", "secret", "secret_key", "changeme", "change_me", "password", "example",
+}
+
+// Config adalah seluruh pengaturan service.
+// Urutan prioritas (yang belakang menimpa yang depan): default, file YAML/JSON, .env, environment, flag.
+// .env tidak menimpa variabel environment yang sudah di-set.
+type Config struct {
+	Port           string        `yaml:"port" json:"port"`
+	MongoURL       string        `yaml:"mongo_url" json:"mongo_url"`
+	Storage        string        `yaml:"storage" json:"storage"`             // mongo atau memory
+	SecretKey      string        `yaml:"secret_key" json:"secret_key"`       // seed kunci JWT pertama, boleh kosong
+	TokenFormats   string        `yaml:"token_formats" json:"token_formats"` // lihat helpers.TokenVerifiers
+	KeyGracePeriod time.Duration `yaml:"-" json:"-"`                         // lama kunci yang sudah di-retire masih diterima
+	// DeletionGracePeriod adalah lama akun yang dihapus masih bisa dipulihkan sebelum dihapus permanen
+	DeletionGracePeriod time.Duration `yaml:"-" json:"-"`
+	// InvitationTTL adalah umur bawaan undangan signup jika admin tidak mengisi expires_at
+	InvitationTTL time.Duration `yaml:"-" json:"-"`
+
+	MailDriver   string `yaml:"mail_driver" json:"mail_driver"` // smtp, file atau memory
+	MailFrom     string `yaml:"mail_from" json:"mail_from"`
+	MailDir      string `yaml:"mail_dir" json:"mail_dir"` // folder email untuk driver file
+	SMTPAddr     string `yaml:"smtp_addr" json:"smtp_addr"`
+	SMTPUsername string `yaml:"smtp_username" json:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" json:"smtp_password"`
+	// PasswordResetURL adalah halaman frontend untuk reset password; token ditambahkan sebagai ?token=
+	PasswordResetURL string `yaml:"password_reset_url" json:"password_reset_url"`
+	// PublicURL adalah alamat service ini dari sisi user, dipakai untuk link verifikasi email
+	PublicURL string `yaml:"public_url" json:"public_url"`
+	// RequireVerifiedEmail membuat Login menolak akun yang emailnya belum diverifikasi
+	RequireVerifiedEmail bool `yaml:"require_verified_email" json:"require_verified_email"`
+	// InviteOnly menutup signup terbuka, sehingga akun baru hanya bisa dibuat lewat undangan admin
+	InviteOnly bool `yaml:"invite_only" json:"invite_only"`
+	// InvitationURL adalah halaman frontend untuk signup lewat undangan; token ditambahkan sebagai ?token=
+	InvitationURL string `yaml:"invitation_url" json:"invitation_url"`
+	// BootstrapAdminEmail diundang sebagai ADMIN saat start selama belum terdaftar, untuk membuat admin pertama
+	BootstrapAdminEmail string `yaml:"bootstrap_admin_email" json:"bootstrap_admin_email"`
+	// RateLimits menimpa policy rate limit bawaan, mis. "login=10/1m,signup=5/1h" (lihat ratelimit.ParsePolicies)
+	RateLimits string `yaml:"rate_limits" json:"rate_limits"`
+	// TrustedProxies adalah IP/CIDR reverse proxy yang header X-Forwarded-For-nya dipercaya, dipisah koma.
+	// Kosong berarti tidak ada proxy yang dipercaya dan IP client selalu diambil dari alamat koneksi.
+	TrustedProxies string `yaml:"trusted_proxies" json:"trusted_proxies"`
+
+	// PasswordHash adalah algoritma untuk hash password baru: argon2id atau bcrypt.
+	// Hash dengan algoritma atau parameter lain diganti otomatis saat user berhasil login.
+	PasswordHash      string `yaml:"password_hash" json:"password_hash"`
+	Argon2Memory      int    `yaml:"argon2_memory" json:"argon2_memory"` // dalam KiB
+	Argon2Iterations  int    `yaml:"argon2_iterations" json:"argon2_iterations"`
+	Argon2Parallelism int    `yaml:"argon2_parallelism" json:"argon2_parallelism"`
+	BcryptCost        int    `yaml:"bcrypt_cost" json:"bcrypt_cost"`
+
+	// Aturan password baru, lihat passpolicy.Policy
+	PasswordMinLength  int `yaml:"password_min_length" json:"password_min_length"`
+	PasswordMaxLength  int `yaml:"password_max_length" json:"password_max_length"`
+	PasswordMinClasses int `yaml:"password_min_classes" json:"password_min_classes"`
+	PasswordHistory    int `yaml:"password_history" json:"password_history"`
+	// BreachedPasswordsFile berisi hash SHA-1 password yang bocor (lihat passpolicy.LoadBlocklist), kosong jika tidak dicek
+	BreachedPasswordsFile string `yaml:"breached_passwords_file" json:"breached_passwords_file"`
+}
+
+// fileConfig sama dengan Config, tetapi durasi ditulis sebagai string (mis. "168h") di file
+type fileConfig struct {
+	Config              `yaml:",inline"`
+	KeyGracePeriod      string `yaml:"key_grace_period" json:"key_grace_period"`
+	DeletionGracePeriod string `yaml:"deletion_grace_period" json:"deletion_grace_period"`
+	InvitationTTL       string `yaml:"invitation_ttl" json:"invitation_ttl"`
+}
+
+// Default mengembalikan nilai bawaan sebelum sumber lain dibaca
+func Default() Config {
+	return Config{
+		Port:                "8080",
+		Storage:             StorageMongo,
+		KeyGracePeriod:      168 * time.Hour,
+		DeletionGracePeriod: 30 * 24 * time.Hour,
+		InvitationTTL:       7 * 24 * time.Hour,
+		MailDriver:          mailer.DriverFile,
+		MailFrom:            "no-reply@localhost",
+		MailDir:             "mail",
+		PasswordHash:        passhash.AlgorithmArgon2id,
+		Argon2Memory:        passhash.DefaultArgon2Memory,
+		Argon2Iterations:    passhash.DefaultArgon2Iterations,
+		Argon2Parallelism:   passhash.DefaultArgon2Parallelism,
+		BcryptCost:          bcrypt.DefaultCost,
+		PasswordMinLength:   passpolicy.DefaultMinLength,
+		PasswordMaxLength:   passpolicy.DefaultMaxLength,
+		PasswordMinClasses:  passpolicy.DefaultMinClasses,
+		PasswordHistory:     passpolicy.DefaultHistory,
+	}
+}
+
+// Load membaca konfigurasi dari semua sumber lalu memvalidasinya.
+// args adalah argumen command line tanpa nama program (os.Args[1:]).
+func Load(args []string) (Config, error) {
+	flags := flag.NewFlagSet("golangsidang", flag.ContinueOnError)
+	configFile := flags.String("config", "", "path file konfigurasi YAML atau JSON (atau env CONFIG_FILE)")
+	envFile := flags.String("env-file", ".env", "path file .env (opsional)")
+	port := flags.String("port", "", "port HTTP")
+	mongoURL := flags.String("mongo-url", "", "connection string MongoDB")
+	storage := flags.String("storage", "", "penyimpanan: mongo atau memory")
+	tokenFormats := flags.String("token-formats", "", "format token yang diterima, dipisah koma")
+	grace := flags.Duration("key-grace-period", 0, "lama kunci yang sudah di-retire masih diterima")
+	requireVerified := flags.Bool("require-verified-email", false, "tolak login akun yang emailnya belum diverifikasi")
+	mailDriver := flags.String("mail-driver", "", "pengirim email: smtp, file atau memory")
+	deletionGrace := flags.Duration("deletion-grace-period", 0, "lama akun yang dihapus masih bisa dipulihkan")
+	passwordHash := flags.String("password-hash", "", "algoritma hash password: argon2id atau bcrypt")
+	inviteOnly := flags.Bool("invite-only", false, "tutup signup terbuka, akun baru hanya lewat undangan")
+	rateLimits := flags.String("rate-limits", "", "policy rate limit per route, mis. login=10/1m,signup=5/1h")
+	trustedProxies := flags.String("trusted-proxies", "", "IP/CIDR reverse proxy yang dipercaya, dipisah koma")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// .env hanya mengisi environment yang belum ada, dan hanya wajib ada jika disebut lewat flag
+	if err := godotenv.Load(*envFile); err != nil && (!os.IsNotExist(err) || isFlagSet(flags, "env-file")) {
+		return Config{}, fmt.Errorf("config: load %s: %w", *envFile, err)
+	}
+
+	config := Default()
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := config.loadEnv(); err != nil {
+		return Config{}, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			config.Port = *port
+		case "mongo-url":
+			config.MongoURL = *mongoURL
+		case "storage":
+			config.Storage = *storage
+		case "token-formats":
+			config.TokenFormats = *tokenFormats
+		case "key-grace-period":
+			config.KeyGracePeriod = *grace
+		case "require-verified-email":
+			config.RequireVerifiedEmail = *requireVerified
+		case "mail-driver":
+			config.MailDriver = *mailDriver
+		case "deletion-grace-period":
+			config.DeletionGracePeriod = *deletionGrace
+		case "password-hash":
+			config.PasswordHash = *passwordHash
+		case "rate-limits":
+			config.RateLimits = *rateLimits
+		case "invite-only":
+			config.InviteOnly = *inviteOnly
+		case "trusted-proxies":
+			config.TrustedProxies = *trustedProxies
+		}
+	})
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate menolak konfigurasi yang tidak lengkap atau memakai secret contoh
+func (c Config) Validate() error {
+	var problems []string
+	if c.Port == "" {
+		problems = append(problems, "port is required")
+	}
+	switch c.Storage {
+	case StorageMongo:
+		if c.MongoURL == "" {
+			problems = append(problems, "MONGO_URL is required when storage is mongo")
+		}
+	case StorageMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown storage %q", c.Storage))
+	}
+	if c.SecretKey != "" {
+		if isPlaceholder(c.SecretKey) {
+			problems = append(problems, "SECRET_KEY is a placeholder value, set a real secret")
+		} else if len(c.SecretKey) < MinSecretKeyLength {
+			problems = append(problems, fmt.Sprintf("SECRET_KEY must be at least %d bytes", MinSecretKeyLength))
+		}
+	}
+	if c.KeyGracePeriod <= 0 {
+		problems = append(problems, "key grace period must be positive")
+	}
+	if c.DeletionGracePeriod <= 0 {
+		problems = append(problems, "deletion grace period must be positive")
+	}
+	if c.InvitationTTL <= 0 {
+		problems = append(problems, "invitation TTL must be positive")
+	}
+	switch c.MailDriver {
+	case mailer.DriverSMTP:
+		if c.SMTPAddr == "" {
+			problems = append(problems, "SMTP_ADDR is required when mail driver is smtp")
+		}
+	case mailer.DriverFile:
+		if c.MailDir == "" {
+			problems = append(problems, "MAIL_DIR is required when mail driver is file")
+		}
+	case mailer.DriverMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown mail driver %q", c.MailDriver))
+	}
+	if _, err := c.PasswordHasher(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.PasswordMinLength < 1 || c.PasswordMaxLength < c.PasswordMinLength {
+		problems = append(problems, "password length limits must satisfy 1 <= min <= max")
+	}
+	if c.PasswordMinClasses < 0 || c.PasswordMinClasses > 4 {
+		problems = append(problems, "password min classes must be between 0 and 4")
+	}
+	if c.PasswordHistory < 0 {
+		problems = append(problems, "password history must not be negative")
+	}
+	if _, err := ratelimit.ParsePolicies(c.RateLimits); err != nil {
+		problems = append(problems, "RATE_LIMITS: "+err.Error())
+	}
+	for _, proxy := range c.TrustedProxyList() {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES: %q is not an IP address or CIDR", proxy))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	file := fileConfig{Config: *c}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		return fmt.Errorf("config: unsupported config file %s (use .yaml, .yml or .json)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	*c = file.Config
+	if file.KeyGracePeriod != "" {
+		if c.KeyGracePeriod, err = time.ParseDuration(file.KeyGracePeriod); err != nil {
+			return fmt.Errorf("config: key_grace_period: %w", err)
+		}
+	}
+	if file.DeletionGracePeriod != "" {
+		if c.DeletionGracePeriod, err = time.ParseDuration(file.DeletionGracePeriod); err != nil {
+			return fmt.Errorf("config: deletion_grace_period: %w", err)
+		}
+	}
+	if file.InvitationTTL != "" {
+		if c.InvitationTTL, err = time.ParseDuration(file.InvitationTTL); err != nil {
+			return fmt.Errorf("config: invitation_ttl: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	setString(&c.Port, "PORT")
+	setString(&c.MongoURL, "MONGO_URL")
+	setString(&c.Storage, "STORAGE")
+	setString(&c.SecretKey, "SECRET_KEY")
+	setString(&c.TokenFormats, "AUTH_TOKEN_FORMATS")
+	setString(&c.MailDriver, "MAIL_DRIVER")
+	setString(&c.MailFrom, "MAIL_FROM")
+	setString(&c.MailDir, "MAIL_DIR")
+	setString(&c.SMTPAddr, "SMTP_ADDR")
+	setString(&c.SMTPUsername, "SMTP_USERNAME")
+	setString(&c.SMTPPassword, "SMTP_PASSWORD")
+	setString(&c.PasswordResetURL, "PASSWORD_RESET_URL")
+	setString(&c.PublicURL, "PUBLIC_URL")
+	setString(&c.InvitationURL, "INVITATION_URL")
+	setString(&c.BootstrapAdminEmail, "BOOTSTRAP_ADMIN_EMAIL")
+	setString(&c.RateLimits, "RATE_LIMITS")
+	setString(&c.TrustedProxies, "TRUSTED_PROXIES")
+	setString(&c.PasswordHash, "PASSWORD_HASH")
+	setString(&c.BreachedPasswordsFile, "BREACHED_PASSWORDS_FILE")
+	for name, field := range map[string]*int{
+		"ARGON2_MEMORY":        &c.Argon2Memory,
+		"ARGON2_ITERATIONS":    &c.Argon2Iterations,
+		"ARGON2_PARALLELISM":   &c.Argon2Parallelism,
+		"BCRYPT_COST":          &c.BcryptCost,
+		"PASSWORD_MIN_LENGTH":  &c.PasswordMinLength,
+		"PASSWORD_MAX_LENGTH":  &c.PasswordMaxLength,
+		"PASSWORD_MIN_CLASSES": &c.PasswordMinClasses,
+		"PASSWORD_HISTORY":     &c.PasswordHistory,
+	} {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("config: %s: %w", name, err)
+			}
+			*field = n
+		}
+	}
+	if value := os.Getenv("REQUIRE_VERIFIED_EMAIL"); value != "" {
+		require, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: REQUIRE_VERIFIED_EMAIL: %w", err)
+		}
+		c.RequireVerifiedEmail = require
+	}
+	if value := os.Getenv("INVITE_ONLY"); value != "" {
+		inviteOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: INVITE_ONLY: %w", err)
+		}
+		c.InviteOnly = inviteOnly
+	}
+	if value := os.Getenv("KEY_GRACE_PERIOD"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("config: KEY_GRACE_PERIOD: %w", err)
+		}
+		c.KeyGracePeriod = grace
+	}
+	if value := os.Getenv("DELETION_GRACE_PERIOD"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("config: DELETION_GRACE_PERIOD: %w", err)
+		}
+		c.DeletionGracePeriod = grace
+	}
+	if value := os.Getenv("INVITATION_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("config: INVITATION_TTL: %w", err)
+		}
+		c.InvitationTTL = ttl
+	}
+	return nil
+}
+
+func setString(field *string, name string) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		*field = value
+	}
+}
+
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func isPlaceholder(secret string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(secret))
+	for _, placeholder := range placeholderSecrets {
+		if normalized == placeholder {
+			return true
+		}
+	}
+	return strings.Contains(normalized, "your_secret") || strings.Contains(normalized, "changeme")
+}
+
+// VerificationURL mengembalikan alamat endpoint verifikasi email, berdasarkan PublicURL atau localhost:Port
+func (c Config) VerificationURL() string {
+	base := strings.TrimRight(c.PublicURL, "/")
+	if base == "" {
+		base = "http://localhost:" + c.Port
+	}
+	return base + "/user/verify"
+}
+
+// TrustedProxyList mengembalikan TrustedProxies sebagai daftar, nil jika tidak ada proxy yang dipercaya
+func (c Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// PasswordHasher membuat Hasher untuk hash password baru sesuai PasswordHash dan parameternya
+func (c Config) PasswordHasher() (passhash.Hasher, error) {
+	if c.Argon2Memory < 0 || int64(c.Argon2Memory) > math.MaxUint32 || c.Argon2Iterations < 0 || int64(c.Argon2Iterations) > math.MaxUint32 {
+		return nil, errors.New("passhash: argon2id memory and iterations must fit in 32 bits")
+	}
+	if c.Argon2Parallelism < 1 || c.Argon2Parallelism > math.MaxUint8 {
+		return nil, fmt.Errorf("passhash: argon2id parallelism must be between 1 and %d", math.MaxUint8)
+	}
+	argon := passhash.Argon2id{Memory: uint32(c.Argon2Memory), Iterations: uint32(c.Argon2Iterations), Parallelism: uint8(c.Argon2Parallelism)}
+	return passhash.NewHasher(c.PasswordHash, argon, c.BcryptCost)
+}
+
+// PasswordPolicy membuat aturan password dari konfigurasi, termasuk membaca BreachedPasswordsFile
+func (c Config) PasswordPolicy() (passpolicy.Policy, error) {
+	policy := passpolicy.Policy{
+		MinLength:  c.PasswordMinLength,
+		MaxLength:  c.PasswordMaxLength,
+		MinClasses: c.PasswordMinClasses,
+		History:    c.PasswordHistory,
+	}
+	if c.BreachedPasswordsFile != "" {
+		blocklist, err := passpolicy.LoadBlocklist(c.BreachedPasswordsFile)
+		if err != nil {
+			return passpolicy.Policy{}, err
+		}
+		policy.Breached = blocklist
+	}
+	return policy, nil
+}
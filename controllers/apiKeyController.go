@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxAPIKeysPerUser membatasi jumlah API key aktif milik satu user
+const MaxAPIKeysPerUser = 25
+
+// CreateAPIKey membuat API key untuk user yang sedang login. Scope hanya boleh berisi permission
+// yang dimiliki user sendiri. Key lengkap hanya dikembalikan di respons ini.
+func CreateAPIKey(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Name       string     `json:"name" validate:"required,min=1,max=100"`
+			Scopes     []string   `json:"scopes"`
+			Expires_at *time.Time `json:"expires_at"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		seen := map[string]bool{}
+		scopes := []string{}
+		for _, scope := range body.Scopes {
+			if !models.IsPermission(scope) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope, "field": "scopes"})
+				return
+			}
+			if !helper.HasPermission(c, scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "cannot grant scope " + scope + " you do not have", "field": "scopes"})
+				return
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		var expiresAt *time.Time
+		if body.Expires_at != nil {
+			expires := body.Expires_at.UTC().Truncate(time.Second)
+			if !expires.After(now) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future", "field": "expires_at"})
+				return
+			}
+			expiresAt = &expires
+		}
+
+		userId := c.GetString("uid")
+		existing, err := services.APIKeys.ListByUser(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(existing) >= MaxAPIKeysPerUser {
+			c.JSON(http.StatusConflict, gin.H{"error": "too many API keys, revoke an unused key first"})
+			return
+		}
+
+		key, prefix, hash, err := helper.NewAPIKey()
+		if err != nil {
+			log.Printf("Error generating API key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "API key was not created"})
+			return
+		}
+		apiKey := models.APIKey{
+			Key_id:     primitive.NewObjectID().Hex(),
+			User_id:    userId,
+			Name:       body.Name,
+			Prefix:     prefix,
+			Key_hash:   hash,
+			Scopes:     scopes,
+			Created_at: now,
+			Expires_at: expiresAt,
+		}
+		if err := services.APIKeys.Create(ctx, apiKey); err != nil {
+			log.Printf("Error creating API key for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "API key was not created"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": apiKey, "message": "store the API key now, it will not be shown again"})
+	}
+}
+
+// GetAPIKeys menampilkan API key aktif milik user yang sedang login, tanpa key lengkapnya
+func GetAPIKeys(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		keys, err := services.APIKeys.ListByUser(ctx, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"total_count": len(keys), "keys": keys})
+	}
+}
+
+// RevokeAPIKey mencabut API key milik user yang sedang login; request berikutnya dengan key itu langsung ditolak
+func RevokeAPIKey(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		keyId := c.Param("key_id")
+		revokedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := services.APIKeys.Revoke(ctx, c.GetString("uid"), keyId, revokedAt)
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error revoking API key %s: %v", keyId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "API key was not revoked"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked", "key_id": keyId})
+	}
+}
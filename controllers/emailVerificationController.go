@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/mailer"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// verificationResendInterval adalah jeda minimal antar email verifikasi untuk user yang sama
+const verificationResendInterval = time.Minute
+
+// sendEmailVerification membuat token verifikasi baru (token lama batal) dan mengirimkannya ke email user
+func sendEmailVerification(ctx context.Context, services *helper.Services, user models.User, mail mailer.Mailer, verifyURL string) error {
+	token, err := services.IssueUserToken(ctx, *user.User_id, models.TokenPurposeEmailVerification, helper.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	message := mailer.Message{
+		To:      *user.Email,
+		Subject: "Verify your email address",
+		Body: "Hi " + *user.First_name + ",\r\n\r\n" +
+			"Open the link below to verify your email address. It expires in " + helper.EmailVerificationTTL.String() + ".\r\n\r\n" +
+			verifyURL + "?token=" + url.QueryEscape(token) + "\r\n\r\n" +
+			"If you did not create an account, you can ignore this email.",
+	}
+	return mail.Send(ctx, message)
+}
+
+// VerifyEmail menandai email user terverifikasi memakai token dari email verifikasi
+func VerifyEmail(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+			return
+		}
+
+		userId, err := services.ConsumeUserToken(ctx, models.TokenPurposeEmailVerification, token)
+		if errors.Is(err, helper.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error consuming email verification token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "email was not verified"})
+			return
+		}
+
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": helper.ErrInvalidUserToken.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		user.Email_verified = true
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error verifying email for user %s: %v", userId, err)
+			userUpdateError(c, err, "email was not verified")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "email verified", "user_id": userId})
+	}
+}
+
+// ResendVerification mengirim ulang email verifikasi, paling sering sekali per verificationResendInterval.
+// Seperti ForgotPassword, respons selalu sama agar tidak membocorkan email mana yang terdaftar.
+func ResendVerification(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, verifyURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !helper.RunInBackground(func() { resendEmailVerification(users, services, mail, verifyURL, body.Email) }) {
+			log.Printf("Verification email dropped: too many emails in progress")
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered and not verified yet, a verification link has been sent"})
+	}
+}
+
+func resendEmailVerification(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, verifyURL string, email string) {
+	// berjalan di latar belakang setelah request selesai, jadi tidak memakai context request
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	user, err := users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user for email verification: %v", err)
+		return
+	}
+	if user.Email_verified {
+		return
+	}
+
+	throttled, err := services.UserTokenIssuedWithin(ctx, *user.User_id, models.TokenPurposeEmailVerification, verificationResendInterval)
+	if err != nil {
+		log.Printf("Error checking email verification throttle for user %s: %v", *user.User_id, err)
+		return
+	}
+	if throttled {
+		return
+	}
+	if err := sendEmailVerification(ctx, services, user, mail, verifyURL); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", *user.User_id, err)
+	}
+}
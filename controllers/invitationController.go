@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/mailer"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateInvitation membuat undangan signup sekali pakai dan mengirim link-nya ke email tujuan.
+// Role selain USER butuh permission roles:assign, sama seperti mengganti user_type.
+// Undangan ke organisasi hanya boleh dibuat oleh anggota organisasi itu yang punya members:write di sana.
+func CreateInvitation(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, invitationURL string, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Email      string     `json:"email" validate:"required,email"`
+			Role       string     `json:"role"`     // default USER
+			Org_id     string     `json:"org_id"`   // opsional, akun baru langsung menjadi anggota
+			Org_role   string     `json:"org_role"` // default ORG_MEMBER
+			Expires_at *time.Time `json:"expires_at"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if body.Role == "" {
+			body.Role = models.RoleUser
+		}
+		if body.Role != models.RoleUser && !helper.HasPermission(c, models.PermissionRolesAssign) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + models.PermissionRolesAssign + " to invite with role " + body.Role})
+			return
+		}
+		if !validRole(ctx, c, services, "role", body.Role) {
+			return
+		}
+
+		var org models.Organization
+		if body.Org_id != "" {
+			var err error
+			org, err = services.Organizations.FindByID(ctx, body.Org_id)
+			if errors.Is(err, repository.ErrOrganizationNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "org_id"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if body.Org_role == "" {
+				body.Org_role = models.RoleOrgMember
+			}
+			if !validOrgRole(c, "org_role", body.Org_role) {
+				return
+			}
+			if !canManageMembers(ctx, c, users, services, body.Org_id) {
+				return
+			}
+		} else if body.Org_role != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "org_role requires org_id", "field": "org_role"})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		expiresAt := now.Add(ttl)
+		if body.Expires_at != nil {
+			expiresAt = body.Expires_at.UTC().Truncate(time.Second)
+			if !expiresAt.After(now) || expiresAt.Sub(now) > helper.MaxInvitationTTL {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future and at most " + helper.MaxInvitationTTL.String() + " away", "field": "expires_at"})
+				return
+			}
+		}
+
+		_, err := users.FindByEmail(ctx, body.Email)
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email is already registered", "field": "email"})
+			return
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		invitation := models.Invitation{
+			Invitation_id: primitive.NewObjectID().Hex(),
+			Email:         body.Email,
+			Role:          body.Role,
+			Org_id:        body.Org_id,
+			Org_role:      body.Org_role,
+			Invited_by:    c.GetString("uid"),
+			Created_at:    now,
+			Expires_at:    expiresAt,
+		}
+		link, err := SendInvitation(ctx, services, mail, invitationURL, &invitation, org)
+		if err != nil {
+			log.Printf("Error creating invitation: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invitation was not created"})
+			return
+		}
+
+		invitation.Status = invitation.StatusAt(now)
+		c.JSON(http.StatusCreated, gin.H{"invitation": invitation, "invitation_url": link})
+	}
+}
+
+// SendInvitation menyimpan undangan lalu mengirim link signup-nya ke invitation.Email dan mengembalikan link tersebut.
+// Gagal kirim email hanya dicatat di log: undangan tetap berlaku dan link-nya bisa dibagikan manual.
+func SendInvitation(ctx context.Context, services *helper.Services, mail mailer.Mailer, invitationURL string, invitation *models.Invitation, org models.Organization) (string, error) {
+	token, err := services.IssueInvitation(ctx, invitation)
+	if err != nil {
+		return "", err
+	}
+	link := token
+	if invitationURL != "" {
+		link = invitationURL + "?token=" + url.QueryEscape(token)
+	}
+
+	to := "create an account"
+	if org.Name != nil {
+		to = "join " + *org.Name
+	}
+	err = mail.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited",
+		Body: "Hi,\r\n\r\n" +
+			"You have been invited to " + to + ". Use the link below to sign up with this email address. " +
+			"It expires at " + invitation.Expires_at.Format(time.RFC1123) + " and can only be used once.\r\n\r\n" +
+			link + "\r\n\r\n" +
+			"If you were not expecting this invitation, you can ignore this email.",
+	})
+	if err != nil {
+		log.Printf("Error sending invitation %s: %v", invitation.Invitation_id, err)
+	}
+	return link, nil
+}
+
+// GetInvitations menampilkan undangan terbaru lebih dulu, bisa difilter dengan ?status=pending|accepted|revoked|expired
+func GetInvitations(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		status := c.Query("status")
+		switch status {
+		case "", models.InvitationPending, models.InvitationAccepted, models.InvitationRevoked, models.InvitationExpired:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown status " + status, "field": "status"})
+			return
+		}
+		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
+		if err != nil || recordPerPage < 1 {
+			recordPerPage = 10
+		}
+		page, err := strconv.Atoi(c.Query("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		now := time.Now()
+		invitations, total, err := services.Invitations.List(ctx, status, now, (page-1)*recordPerPage, recordPerPage)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range invitations {
+			invitations[i].Status = invitations[i].StatusAt(now)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"total_count": total, "invitations": invitations})
+	}
+}
+
+// RevokeInvitation mencabut undangan yang belum dipakai sehingga link-nya tidak bisa dipakai lagi
+func RevokeInvitation(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		invitationId := c.Param("invitation_id")
+		invitation, err := services.Invitations.FindByID(ctx, invitationId)
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if status := invitation.StatusAt(time.Now()); status == models.InvitationAccepted || status == models.InvitationRevoked {
+			c.JSON(http.StatusConflict, gin.H{"error": "invitation is already " + status})
+			return
+		}
+
+		revokedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = services.Invitations.Revoke(ctx, invitationId, revokedAt)
+		if errors.Is(err, repository.ErrInvitationNotFound) {
+			// baru saja dipakai atau dicabut oleh permintaan lain
+			c.JSON(http.StatusConflict, gin.H{"error": "invitation is no longer pending"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error revoking invitation %s: %v", invitationId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invitation was not revoked"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "invitation revoked", "invitation_id": invitationId})
+	}
+}
@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"golangsidang/totp"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollTOTP membuat secret TOTP baru untuk user yang sedang login. MFA baru aktif setelah ConfirmTOTP.
+func EnrollTOTP(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.GetString("uid")
+
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Mfa_enabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			log.Printf("Error generating TOTP secret: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start enrollment"})
+			return
+		}
+		user.Mfa_pending_secret = &secret
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error saving TOTP secret for user %s: %v", userId, err)
+			userUpdateError(c, err, "failed to start enrollment")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"secret": secret, "otpauth_uri": totp.URI(helper.MFAIssuer, *user.Email, secret)})
+	}
+}
+
+// ConfirmTOTP mengaktifkan MFA jika kode pertama dari authenticator cocok, lalu mengembalikan recovery code.
+// Recovery code hanya ditampilkan sekali; yang disimpan hanya hash-nya.
+func ConfirmTOTP(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Code string `json:"code" validate:"required,len=6,numeric"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userId := c.GetString("uid")
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Mfa_enabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+			return
+		}
+		if user.Mfa_pending_secret == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start enrollment first"})
+			return
+		}
+
+		step, ok := totp.Validate(*user.Mfa_pending_secret, body.Code, time.Now())
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid code"})
+			return
+		}
+		codes, hashes, err := helper.NewRecoveryCodes()
+		if err != nil {
+			log.Printf("Error generating recovery codes: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+			return
+		}
+
+		user.Mfa_secret = user.Mfa_pending_secret
+		user.Mfa_pending_secret = nil
+		user.Mfa_enabled = true
+		user.Mfa_last_step = step
+		user.Mfa_recovery_codes = hashes
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error enabling MFA for user %s: %v", userId, err)
+			userUpdateError(c, err, "failed to enable two-factor authentication")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled", "recovery_codes": codes})
+	}
+}
+
+// LoginMFA menyelesaikan login dua langkah: token tantangan dari Login ditukar dengan kode TOTP atau recovery code
+func LoginMFA(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Mfa_token     string `json:"mfa_token" validate:"required"`
+			Code          string `json:"code" validate:"required_without=Recovery_code,omitempty,len=6,numeric"`
+			Recovery_code string `json:"recovery_code"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		challenge, err := services.ConsumeMFAChallenge(ctx, body.Mfa_token)
+		if errors.Is(err, helper.ErrInvalidUserToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA challenge is invalid or expired, log in again"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error consuming MFA challenge: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+			return
+		}
+
+		foundUser, err := users.FindByID(ctx, challenge.User_id)
+		if err != nil || !foundUser.Mfa_enabled || foundUser.Mfa_secret == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA challenge is invalid or expired, log in again"})
+			return
+		}
+
+		// kode yang salah dihitung bersama password salah, sehingga lockout email dan IP juga berlaku di langkah ini
+		retryAfter, err := services.LoginAttempts.Check(ctx, *foundUser.Email, c.ClientIP())
+		if err != nil {
+			log.Printf("Error checking login attempts: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+			return
+		}
+		if retryAfter > 0 {
+			c.Header("Retry-After", helper.RetryAfterSeconds(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+			return
+		}
+
+		if !verifySecondFactor(&foundUser, body.Code, body.Recovery_code) {
+			recordLoginFailure(ctx, services, *foundUser.Email, c.ClientIP())
+			err := services.RetryMFAChallenge(ctx, challenge)
+			if errors.Is(err, helper.ErrTooManyOTPAttempts) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many invalid codes, log in again"})
+				return
+			}
+			if err != nil {
+				log.Printf("Error saving MFA challenge: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+
+		// langkah TOTP atau recovery code yang terpakai disimpan agar tidak bisa dipakai ulang
+		foundUser.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, foundUser); err != nil {
+			log.Printf("Error updating MFA state for user %s: %v", challenge.User_id, err)
+			userUpdateError(c, err, "error occurred")
+			return
+		}
+
+		recordLoginSuccess(ctx, services, *foundUser.Email, c.ClientIP())
+		completeLogin(ctx, c, services, foundUser)
+	}
+}
+
+// verifySecondFactor mengecek kode TOTP (menolak langkah yang sudah dipakai) atau recovery code, dan mencatat pemakaiannya di user
+func verifySecondFactor(user *models.User, code string, recoveryCode string) bool {
+	if code != "" {
+		step, ok := totp.Validate(*user.Mfa_secret, code, time.Now())
+		if !ok || step <= user.Mfa_last_step {
+			return false
+		}
+		user.Mfa_last_step = step
+		return true
+	}
+	return helper.UseRecoveryCode(user, recoveryCode)
+}
+
+// ResetMFA mematikan MFA user yang kehilangan authenticator dan recovery code-nya, butuh permission users:security
+func ResetMFA(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.Param("user_id")
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		user.Mfa_enabled = false
+		user.Mfa_secret = nil
+		user.Mfa_pending_secret = nil
+		user.Mfa_last_step = 0
+		user.Mfa_recovery_codes = nil
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error resetting MFA for user %s: %v", userId, err)
+			userUpdateError(c, err, "failed to reset two-factor authentication")
+			return
+		}
+		if err := services.UserTokens.DeleteByUser(ctx, userId, models.TokenPurposeMFAChallenge); err != nil {
+			log.Printf("Error cancelling MFA challenges for user %s: %v", userId, err)
+		}
+		log.Printf("MFA for user %s reset by admin %s", userId, c.GetString("uid"))
+
+		c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset", "user_id": userId})
+	}
+}
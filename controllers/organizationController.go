@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// member adalah data anggota yang boleh dilihat admin organisasi
+type member struct {
+	User_id    string    `json:"user_id"`
+	First_name string    `json:"first_name"`
+	Last_name  string    `json:"last_name"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	Joined_at  time.Time `json:"joined_at"`
+}
+
+func memberOf(user models.User, membership models.Membership) member {
+	return member{
+		User_id:    *user.User_id,
+		First_name: *user.First_name,
+		Last_name:  *user.Last_name,
+		Email:      *user.Email,
+		Role:       membership.Role,
+		Joined_at:  membership.Joined_at,
+	}
+}
+
+// CreateOrganization membuat organisasi baru dengan pembuatnya sebagai ORG_ADMIN
+func CreateOrganization(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var org models.Organization
+		if err := c.BindJSON(&org); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(org); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userId := c.GetString("uid")
+		org.Org_id = primitive.NewObjectID().Hex()
+		org.Created_by = userId
+		org.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		org.Updated_at = org.Created_at
+		if err := services.Organizations.Create(ctx, org); err != nil {
+			log.Printf("Error creating organization: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "organization was not created"})
+			return
+		}
+		membership := models.Membership{Org_id: org.Org_id, Role: models.RoleOrgAdmin, Joined_at: org.Created_at}
+		if err := users.AddMembership(ctx, userId, membership); err != nil {
+			log.Printf("Error adding creator to organization %s: %v", org.Org_id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "organization was not created"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, org)
+	}
+}
+
+// GetOrganizations menampilkan organisasi tempat user yang sedang login menjadi anggota, beserta role-nya
+func GetOrganizations(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		user, err := users.FindByID(ctx, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var orgIds []string
+		for _, membership := range user.Memberships {
+			orgIds = append(orgIds, membership.Org_id)
+		}
+		orgs, err := services.Organizations.FindByIDs(ctx, orgIds)
+		if err != nil {
+			log.Printf("Error loading organizations: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load organizations"})
+			return
+		}
+
+		result := []gin.H{}
+		for _, org := range orgs {
+			membership, _ := helper.MembershipOf(user, org.Org_id)
+			result = append(result, gin.H{"org_id": org.Org_id, "name": org.Name, "role": membership.Role, "joined_at": membership.Joined_at})
+		}
+		c.JSON(http.StatusOK, gin.H{"organizations": result, "active_org_id": c.GetString("tenant_id")})
+	}
+}
+
+// SwitchOrganization mengganti organisasi aktif: sesi sekarang diakhiri dan sesi baru
+// di organisasi tersebut dibuat, dengan respons yang sama seperti login
+func SwitchOrganization(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		orgId := c.Param("org_id")
+		userId := c.GetString("uid")
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := helper.MembershipOf(user, orgId); !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not a member of the organization"})
+			return
+		}
+
+		if jti := c.GetString("jti"); jti != "" {
+			if err := services.Revocations.Revoke(ctx, jti, c.GetTime("token_expires_at")); err != nil {
+				log.Printf("Error revoking token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to switch organization"})
+				return
+			}
+		}
+		err = services.DeleteSession(ctx, userId, c.GetString("session_id"))
+		if err != nil && !errors.Is(err, helper.ErrSessionNotFound) {
+			log.Printf("Error ending session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to switch organization"})
+			return
+		}
+
+		startSession(ctx, c, services, user, orgId)
+	}
+}
+
+// GetMembers menampilkan anggota organisasi aktif; query dibatasi ke organisasi oleh middleware.Tenant
+func GetMembers(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
+		if err != nil || recordPerPage < 1 {
+			recordPerPage = 10
+		}
+		page, err := strconv.Atoi(c.Query("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		found, total, err := users.List(ctx, (page-1)*recordPerPage, recordPerPage)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		orgId := c.GetString("tenant_id")
+		members := []member{}
+		for _, user := range found {
+			membership, _ := helper.MembershipOf(user, orgId)
+			members = append(members, memberOf(user, membership))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"total_count": total, "members": members})
+	}
+}
+
+// AddMember menambahkan user yang sudah terdaftar ke organisasi aktif berdasarkan email
+func AddMember(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Email string `json:"email" validate:"required,email"`
+			Role  string `json:"role" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validOrgRole(c, "role", body.Role) {
+			return
+		}
+
+		// calon anggota memang belum ada di organisasi, jadi sengaja dicari lintas tenant
+		user, err := users.FindByEmail(repository.WithoutTenant(ctx), body.Email)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		joinedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		membership := models.Membership{Org_id: c.GetString("tenant_id"), Role: body.Role, Joined_at: joinedAt}
+		err = users.AddMembership(ctx, *user.User_id, membership)
+		if errors.Is(err, repository.ErrMembershipExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error adding member to organization %s: %v", membership.Org_id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "member was not added"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, memberOf(user, membership))
+	}
+}
+
+// UpdateMember mengganti role anggota di organisasi aktif; sesi anggota di organisasi ini diakhiri
+// supaya permission barunya langsung berlaku
+func UpdateMember(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Role string `json:"role" validate:"required"`
+		}
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validOrgRole(c, "role", body.Role) {
+			return
+		}
+
+		orgId := c.GetString("tenant_id")
+		user, membership, ok := findMember(ctx, c, users, orgId)
+		if !ok {
+			return
+		}
+
+		// repository menolak penurunan ORG_ADMIN terakhir secara atomik
+		err := users.SetMembershipRole(ctx, *user.User_id, orgId, body.Role)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrLastOrgAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating member of organization %s: %v", orgId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "member was not updated"})
+			return
+		}
+		if err := services.EndOrganizationSessions(ctx, *user.User_id, orgId); err != nil {
+			log.Printf("Error ending organization sessions for user %s: %v", *user.User_id, err)
+		}
+
+		membership.Role = body.Role
+		c.JSON(http.StatusOK, memberOf(user, membership))
+	}
+}
+
+// RemoveMember mengeluarkan anggota dari organisasi aktif beserta sesinya di organisasi ini
+func RemoveMember(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		orgId := c.GetString("tenant_id")
+		user, _, ok := findMember(ctx, c, users, orgId)
+		if !ok {
+			return
+		}
+
+		// repository menolak pengeluaran ORG_ADMIN terakhir secara atomik
+		err := users.RemoveMembership(ctx, *user.User_id, orgId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrLastOrgAdmin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error removing member of organization %s: %v", orgId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "member was not removed"})
+			return
+		}
+		if err := services.EndOrganizationSessions(ctx, *user.User_id, orgId); err != nil {
+			log.Printf("Error ending organization sessions for user %s: %v", *user.User_id, err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "member removed", "user_id": *user.User_id})
+	}
+}
+
+// findMember mencari anggota dari parameter user_id di organisasi aktif; jika gagal, respons sudah dikirim
+func findMember(ctx context.Context, c *gin.Context, users repository.UserRepository, orgId string) (models.User, models.Membership, bool) {
+	user, err := users.FindByID(ctx, c.Param("user_id"))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return models.User{}, models.Membership{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return models.User{}, models.Membership{}, false
+	}
+	membership, _ := helper.MembershipOf(user, orgId)
+	return user, membership, true
+}
+
+// validOrgRole menolak role yang bukan role keanggotaan organisasi (mis. ADMIN) dengan 400
+func validOrgRole(c *gin.Context, field string, role string) bool {
+	if !models.IsOrgRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role " + role + " is not an organization role, use " + models.RoleOrgAdmin + " or " + models.RoleOrgMember, "field": field})
+		return false
+	}
+	return true
+}
+
+// canManageMembers mengecek bahwa user yang sedang login adalah anggota orgId dengan permission members:write di sana
+func canManageMembers(ctx context.Context, c *gin.Context, users repository.UserRepository, services *helper.Services, orgId string) bool {
+	user, err := users.FindByID(ctx, c.GetString("uid"))
+	if err != nil {
+		log.Printf("Error loading user %s: %v", c.GetString("uid"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+		return false
+	}
+	if membership, ok := helper.MembershipOf(user, orgId); ok {
+		permissions, err := services.PermissionsFor(ctx, membership.Role)
+		if err != nil {
+			log.Printf("Error loading role %s: %v", membership.Role, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+			return false
+		}
+		for _, permission := range permissions {
+			if permission == models.PermissionMembersWrite {
+				return true
+			}
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + models.PermissionMembersWrite + " in organization " + orgId, "field": "org_id"})
+	return false
+}
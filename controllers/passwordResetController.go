@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/mailer"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passwordResetResendInterval adalah jeda minimal antar email reset password untuk user yang sama
+const passwordResetResendInterval = time.Minute
+
+// ForgotPassword mengirim token reset password ke email user, paling sering sekali per passwordResetResendInterval.
+// Respons selalu sama agar tidak bisa dipakai untuk menebak email mana yang terdaftar.
+func ForgotPassword(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, resetURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// dikerjakan di background supaya waktu respons tidak membocorkan apakah email terdaftar
+		if !helper.RunInBackground(func() { sendPasswordReset(users, services, mail, resetURL, body.Email) }) {
+			log.Printf("Password reset email dropped: too many emails in progress")
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a password reset link has been sent"})
+	}
+}
+
+func sendPasswordReset(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, resetURL string, email string) {
+	// berjalan di latar belakang setelah request selesai, jadi tidak memakai context request
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	user, err := users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user for password reset: %v", err)
+		return
+	}
+
+	throttled, err := services.UserTokenIssuedWithin(ctx, *user.User_id, models.TokenPurposePasswordReset, passwordResetResendInterval)
+	if err != nil {
+		log.Printf("Error checking password reset throttle for user %s: %v", *user.User_id, err)
+		return
+	}
+	if throttled {
+		return
+	}
+
+	token, err := services.IssueUserToken(ctx, *user.User_id, models.TokenPurposePasswordReset, helper.PasswordResetTTL)
+	if err != nil {
+		log.Printf("Error issuing password reset token for user %s: %v", *user.User_id, err)
+		return
+	}
+
+	link := token
+	if resetURL != "" {
+		link = resetURL + "?token=" + url.QueryEscape(token)
+	}
+	message := mailer.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + *user.First_name + ",\r\n\r\n" +
+			"Use the link below to reset your password. It expires in " + helper.PasswordResetTTL.String() + " and can only be used once.\r\n\r\n" +
+			link + "\r\n\r\n" +
+			"If you did not ask for a password reset, you can ignore this email.",
+	}
+	if err := mail.Send(ctx, message); err != nil {
+		log.Printf("Error sending password reset email to user %s: %v", *user.User_id, err)
+	}
+}
+
+// ResetPassword mengganti password memakai token dari ForgotPassword, lalu mengakhiri semua sesi user
+func ResetPassword(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Token        string `json:"token" validate:"required"`
+			New_password string `json:"new_password" validate:"required"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// aturan yang tidak bergantung pada user dicek sebelum token dipakai
+		if err := services.PasswordPolicy.Check(body.New_password); err != nil {
+			passwordError(c, err)
+			return
+		}
+
+		resetToken, err := services.TakeUserToken(ctx, models.TokenPurposePasswordReset, body.Token)
+		if errors.Is(err, helper.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error consuming password reset token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password was not reset"})
+			return
+		}
+
+		userId := resetToken.User_id
+
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": helper.ErrInvalidUserToken.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// password yang memuat nama/email atau pernah dipakai ditolak, token dikembalikan supaya bisa dicoba lagi
+		policyErr := services.CheckPassword(user, body.New_password)
+		if policyErr == nil {
+			policyErr = services.CheckPasswordHistory(user, body.New_password)
+		}
+		if policyErr != nil {
+			if err := services.RestoreUserToken(ctx, resetToken); err != nil {
+				log.Printf("Error restoring password reset token for user %s: %v", userId, err)
+			}
+			passwordError(c, policyErr)
+			return
+		}
+
+		password, err := HashPassword(services, body.New_password)
+		if err != nil {
+			log.Printf("Error hashing password for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password was not reset"})
+			return
+		}
+		services.SetPassword(&user, password)
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error resetting password for user %s: %v", userId, err)
+			userUpdateError(c, err, "password was not reset")
+			return
+		}
+
+		// siapa pun yang masih login dengan password lama harus login ulang
+		if err := services.RevokeUserSessions(ctx, userId); err != nil {
+			log.Printf("Error revoking sessions for user %s: %v", userId, err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password has been reset"})
+	}
+}
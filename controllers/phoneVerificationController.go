@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/models"
+	"golangsidang/phone"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// otpResendInterval adalah jeda minimal antar SMS OTP untuk user yang sama
+const otpResendInterval = time.Minute
+
+// SendPhoneOTP mengirim kode OTP ke nomor telepon user yang sedang login
+func SendPhoneOTP(users repository.UserRepository, services *helper.Services, sms phone.SMSSender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.GetString("uid")
+
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Phone_verified {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone is already verified"})
+			return
+		}
+
+		throttled, err := services.UserTokenIssuedWithin(ctx, userId, models.TokenPurposePhoneVerification, otpResendInterval)
+		if err != nil {
+			log.Printf("Error checking OTP throttle for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send code"})
+			return
+		}
+		if throttled {
+			c.Header("Retry-After", strconv.Itoa(int(otpResendInterval.Seconds())))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "a code was sent recently, try again later"})
+			return
+		}
+
+		code, err := services.IssueOTP(ctx, userId, models.TokenPurposePhoneVerification)
+		if err != nil {
+			log.Printf("Error issuing OTP for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send code"})
+			return
+		}
+		body := "Your verification code is " + code + ". It expires in " + helper.OTPTTL.String() + "."
+		if err := sms.Send(ctx, *user.Phone, body); err != nil {
+			log.Printf("Error sending OTP SMS to user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send code"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "verification code sent", "phone": *user.Phone})
+	}
+}
+
+// VerifyPhone menandai nomor telepon user terverifikasi jika kode OTP cocok
+func VerifyPhone(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Code string `json:"code" validate:"required,len=6,numeric"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userId := c.GetString("uid")
+		err := services.VerifyOTP(ctx, userId, models.TokenPurposePhoneVerification, body.Code)
+		if errors.Is(err, helper.ErrInvalidUserToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+			return
+		}
+		if errors.Is(err, helper.ErrTooManyOTPAttempts) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error verifying OTP for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "phone was not verified"})
+			return
+		}
+
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		user.Phone_verified = true
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error verifying phone for user %s: %v", userId, err)
+			userUpdateError(c, err, "phone was not verified")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "phone verified", "phone": *user.Phone})
+	}
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	helper "golangsidang/helpers"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleBody adalah isi request untuk membuat atau mengubah role
+type roleBody struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles menampilkan semua role beserta daftar permission yang bisa diberikan
+func GetRoles(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		roles, err := services.Roles.List(ctx)
+		if err != nil {
+			log.Printf("Error listing roles: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load roles"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": models.Permissions})
+	}
+}
+
+// CreateRole membuat role baru dengan permission dari models.Permissions
+func CreateRole(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body roleBody
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		role := models.Role{Name: body.Name, Description: body.Description, Created_at: now, Updated_at: now}
+		if !setRolePermissions(c, &role, body.Permissions) {
+			return
+		}
+
+		err := services.Roles.Create(ctx, role)
+		if errors.Is(err, repository.ErrRoleExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "field": "name"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error creating role %s: %v", role.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "role was not created"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, role)
+	}
+}
+
+// UpdateRole mengganti deskripsi dan permission role. Token yang sudah terbit tetap membawa
+// permission lama sampai di-refresh. Role ADMIN tidak bisa diubah supaya selalu ada yang bisa mengelola role.
+func UpdateRole(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		name := c.Param("name")
+		if name == models.RoleAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "built-in role " + name + " cannot be changed"})
+			return
+		}
+		var body roleBody
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		role, err := services.Roles.FindByName(ctx, name)
+		if errors.Is(err, repository.ErrRoleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		role.Description = body.Description
+		if !setRolePermissions(c, &role, body.Permissions) {
+			return
+		}
+		role.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = services.Roles.Update(ctx, role)
+		if errors.Is(err, repository.ErrRoleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error updating role %s: %v", name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "role was not updated"})
+			return
+		}
+
+		c.JSON(http.StatusOK, role)
+	}
+}
+
+// DeleteRole menghapus role selain role bawaan; user yang masih memakainya tidak lagi mendapat permission apa pun
+func DeleteRole(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		name := c.Param("name")
+		if models.IsBuiltinRole(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "built-in role " + name + " cannot be deleted"})
+			return
+		}
+
+		err := services.Roles.Delete(ctx, name)
+		if errors.Is(err, repository.ErrRoleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error deleting role %s: %v", name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "role was not deleted"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "role deleted", "name": name})
+	}
+}
+
+// setRolePermissions memvalidasi role lalu mengisi permission tanpa duplikat.
+// Seperti scope API key, pemanggil hanya boleh memberikan permission yang ia miliki sendiri.
+// Jika ada yang tidak valid, respons 400 atau 403 sudah dikirim.
+func setRolePermissions(c *gin.Context, role *models.Role, permissions []string) bool {
+	seen := map[string]bool{}
+	role.Permissions = []string{}
+	for _, permission := range permissions {
+		if !models.IsPermission(permission) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown permission " + permission, "field": "permissions"})
+			return false
+		}
+		if !helper.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot grant permission " + permission + " you do not have", "field": "permissions"})
+			return false
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+	if err := validate.Struct(role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
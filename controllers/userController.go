@@ -2,122 +2,225 @@ package controllers
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"golangsidang/database"
+	"golangsidang/mailer"
 	"golangsidang/models"
+	"golangsidang/passhash"
+	"golangsidang/passpolicy"
+	"golangsidang/phone"
+	"golangsidang/repository"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 
 	helper "golangsidang/helpers"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/go-playground/validator/v10"
-	"github.com/o1egl/paseto/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user") // membuat collection baru
 // Validation instance
 var validate = validator.New()
 
-// HashPassword hashes the plain password
-func HashPassword(password string) string {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hash)
+// HashPassword hashes the plain password dengan algoritma dari konfigurasi (argon2id atau bcrypt)
+func HashPassword(services *helper.Services, password string) (string, error) {
+	return services.Passwords.Hash(password)
 }
 
-func VerifyPassword(userPassword string, providedPassword string) (bool, string) { // membuat fungsi VerifyPassword
-	err := bcrypt.CompareHashAndPassword([]byte(providedPassword), []byte(userPassword))
+func VerifyPassword(services *helper.Services, userPassword string, providedPassword string) (bool, string) { // membuat fungsi VerifyPassword
+	err := services.Passwords.Verify(providedPassword, userPassword)
 	check := true
 	msg := ""
 	if err != nil {
+		if !errors.Is(err, passhash.ErrMismatch) {
+			log.Printf("Error verifying password: %v", err)
+		}
 		msg = fmt.Sprintln("Password doesn't match")
 		check = false
 	}
 	return check, msg // jika password tidak sama dengan providedPassword
 }
 
-// Generate a random PASETO secret key
-func generatePasetoKey() ([]byte, error) {
-	key := make([]byte, 32)
-	_, err := rand.Read(key)
+// validRole memastikan role ada; jika tidak, respons 400 (dengan nama field) atau 500 sudah dikirim
+func validRole(ctx context.Context, c *gin.Context, services *helper.Services, field string, role string) bool {
+	ok, err := services.ValidRole(ctx, role)
 	if err != nil {
-		return nil, err
+		log.Printf("Error loading role %s: %v", role, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role " + role, "field": field})
+		return false
 	}
-	return key, nil
+	return true
 }
 
-func generatePasetoToken(subject string) (string, error) {
-	pasetoSecret, err := generatePasetoKey()
-	if err != nil {
-		return "", err
+// passwordError mengirim password yang ditolak sebagai 400, beserta daftar aturan yang dilanggar
+func passwordError(c *gin.Context, err error) {
+	var violation *passpolicy.ViolationError
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "problems": violation.Problems})
+		return
 	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
 
-	tokenClaims := paseto.JSONToken{
-		Subject:    subject,
-		Expiration: time.Now().Add(24 * time.Hour),
+// userUpdateError mengirim ErrUserConflict sebagai 409 agar client bisa mengulang request, error lain sebagai 500
+func userUpdateError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrUserConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
 
-	pasetoToken, err := paseto.NewV2().Encrypt(pasetoSecret, tokenClaims, nil)
+// upgradePasswordHash mengganti hash lama (bcrypt atau parameter lama) setelah password terbukti benar.
+// Kegagalan hanya dicatat, login tetap berjalan dengan hash lama.
+func upgradePasswordHash(ctx context.Context, users repository.UserRepository, services *helper.Services, user *models.User, password string) {
+	if !services.Passwords.NeedsRehash(*user.Password) {
+		return
+	}
+	hash, err := HashPassword(services, password)
+	if err == nil {
+		user.Password = &hash
+		err = users.Update(ctx, *user)
+	}
 	if err != nil {
-		return "", err
+		log.Printf("Error upgrading password hash for user %s: %v", *user.User_id, err)
 	}
-
-	return pasetoToken, nil
 }
 
-// Generate a random JWT secret key
-func generateJWTKey() ([]byte, error) {
-	key := make([]byte, 64) // You can adjust the key size as needed
-	_, err := rand.Read(key)
+// sessionClaims menyusun claims token (JWT, PASETO local dan public) dari data user dan sesinya.
+// Permission dibaca dari role saat ini; jika sesi berada di organisasi tempat user masih menjadi anggota,
+// tid dan permission role keanggotaannya ikut dicantumkan.
+func sessionClaims(ctx context.Context, services *helper.Services, user models.User, session models.Session) (helper.Claims, error) {
+	permissions, err := services.PermissionsFor(ctx, *user.User_type)
 	if err != nil {
-		return nil, err
+		return helper.Claims{}, fmt.Errorf("load role permissions: %w", err)
 	}
-	return key, nil
+	claims := helper.Claims{
+		Email:       *user.Email,
+		FirstName:   *user.First_name,
+		LastName:    *user.Last_name,
+		Uid:         *user.User_id,
+		UserType:    *user.User_type,
+		Permissions: permissions,
+		Sid:         session.Session_id,
+	}
+	if membership, ok := helper.MembershipOf(user, session.Org_id); ok {
+		orgPermissions, err := services.PermissionsFor(ctx, membership.Role)
+		if err != nil {
+			return helper.Claims{}, fmt.Errorf("load organization role permissions: %w", err)
+		}
+		claims.Tid = membership.Org_id
+		claims.OrgPermissions = orgPermissions
+	}
+	return claims, nil
 }
 
-func generatePublicPasetoToken(subject string) (string, error) {
-	pasetoToken, err := generatePasetoToken(subject)
-	if err != nil {
-		return "", err
-	}
-	return pasetoToken, nil
+// sessionTokens adalah token milik satu sesi. Token tidak disimpan di dokumen user,
+// hanya dikirim di respons login, refresh dan pindah organisasi.
+type sessionTokens struct {
+	Token              string `json:"token"`
+	Refresh_token      string `json:"refresh_token"`
+	Paseto_token       string `json:"paseto_token"`
+	PublicPaseto_token string `json:"public_paseto_token"`
 }
 
-// Fungsi untuk mendekripsi token PASETO versi publik
-func decryptPublicPasetoToken(publicPasetoToken string, publicKey []byte) (string, error) {
-	v2 := paseto.NewV2()
+// loginResponse adalah data user beserta token sesi barunya
+type loginResponse struct {
+	models.User
+	sessionTokens
+}
 
-	// Deklarasikan variabel untuk menyimpan payload
-	var payload string
+// issueSessionTokens menerbitkan token PASETO (local dan public), JWT dan refresh token yang terikat pada session
+func issueSessionTokens(ctx context.Context, services *helper.Services, user models.User, session models.Session) (sessionTokens, error) {
+	claims, err := sessionClaims(ctx, services, user, session)
+	if err != nil {
+		return sessionTokens{}, err
+	}
 
-	// Proses verifikasi token PASETO versi publik
-	err := v2.Verify(publicPasetoToken, publicKey, &payload, nil)
+	// Generate token PASETO for private use
+	pasetoToken, err := services.GenerateToken(claims, helper.AccessTokenTTL)
 	if err != nil {
-		return "", err
+		return sessionTokens{}, fmt.Errorf("generate PASETO token: %w", err)
 	}
 
-	return payload, nil
+	// Generate token PASETO for public verification
+	publicPasetoToken, err := services.GeneratePublicPasetoToken(claims, helper.AccessTokenTTL)
+	if err != nil {
+		return sessionTokens{}, fmt.Errorf("generate public PASETO token: %w", err)
+	}
+
+	// Generate token JWT dan refresh token dengan kunci aktif dari keystore
+	jwtToken, refreshToken, err := services.GenerateAllTokens(claims)
+	if err != nil {
+		return sessionTokens{}, fmt.Errorf("generate JWT token: %w", err)
+	}
+	return sessionTokens{Token: jwtToken, Refresh_token: refreshToken, Paseto_token: pasetoToken, PublicPaseto_token: publicPasetoToken}, nil
 }
 
-// Signup function
-func Signup() gin.HandlerFunc {
+// Signup mendaftarkan user baru. Dengan invitation_token, role dan organisasi diambil dari undangan admin;
+// tanpa undangan hanya role USER yang boleh dipilih, dan jika inviteOnly signup ditolak sama sekali.
+func Signup(users repository.UserRepository, services *helper.Services, mail mailer.Mailer, verifyURL string, inviteOnly bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		var user models.User
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			models.User
+			Password         *string `json:"password"` // User.Password tidak dibaca dari JSON
+			Invitation_token string  `json:"invitation_token"`
+		}
 
-		if err := c.BindJSON(&user); err != nil {
+		if err := c.BindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		user := body.User
+		user.Password = body.Password
+
+		var invitation *models.Invitation
+		if body.Invitation_token != "" {
+			found, err := services.FindInvitation(ctx, body.Invitation_token)
+			if errors.Is(err, helper.ErrInvalidInvitation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "invitation_token"})
+				return
+			}
+			if err != nil {
+				log.Printf("Error loading invitation: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+				return
+			}
+			if user.Email == nil || !strings.EqualFold(*user.Email, found.Email) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "email does not match the invitation", "field": "email"})
+				return
+			}
+			// email dan role dari undangan menggantikan yang dikirim pendaftar
+			user.Email = &found.Email
+			user.User_type = &found.Role
+			invitation = &found
+		} else if inviteOnly {
+			c.JSON(http.StatusForbidden, gin.H{"error": "signup is by invitation only"})
+			return
+		} else if user.User_type != nil && *user.User_type != models.RoleUser {
+			c.JSON(http.StatusForbidden, gin.H{"error": "role " + *user.User_type + " can only be granted through an invitation", "field": "user_type"})
+			return
+		}
+		// phone disimpan dalam format E.164 supaya "0812..." dan "+62812..." dianggap nomor yang sama
+		if user.Phone != nil {
+			normalized, err := phone.Normalize(*user.Phone, phone.DefaultRegion)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "phone"})
+				return
+			}
+			user.Phone = &normalized
+		}
 
 		validateErr := validate.Struct(user)
 		if validateErr != nil {
@@ -125,29 +228,26 @@ func Signup() gin.HandlerFunc {
 			return
 		}
 
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
-		defer cancel()
-		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+		if err := services.CheckPassword(user, *user.Password); err != nil {
+			passwordError(c, err)
 			return
 		}
-
-		password := HashPassword(*user.Password)
-		user.Password = &password
-
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
-		defer cancel()
-		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+		if !validRole(ctx, c, services, "user_type", *user.User_type) {
 			return
 		}
 
-		if count > 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "email already exists"})
+		password, err := HashPassword(services, *user.Password)
+		if err != nil {
+			log.Printf("Error hashing password: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
 			return
 		}
+		user.Password = &password
+		// status akun tidak boleh diisi sendiri oleh pendaftar
+		user.Email_verified = false
+		user.Phone_verified = false
+		user.Deleted_at = nil
+		user.Memberships = nil
 
 		user.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -155,176 +255,374 @@ func Signup() gin.HandlerFunc {
 		userID := user.ID.Hex()
 		user.User_id = &userID
 
-		// Generate token PASETO for private use
-		pasetoToken, err := generatePasetoToken(*user.User_id)
-		if err != nil {
-			log.Printf("Error generating PASETO token: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PASETO token"})
+		// undangan dipakai sekarang (atomik) agar tidak bisa dipakai dua pendaftar sekaligus
+		if invitation != nil {
+			accepted, err := services.AcceptInvitation(ctx, body.Invitation_token, userID)
+			if errors.Is(err, helper.ErrInvalidInvitation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "invitation_token"})
+				return
+			}
+			if err != nil {
+				log.Printf("Error accepting invitation: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+				return
+			}
+			invitation = &accepted
+			// undangan terkirim ke email ini, jadi email sudah terbukti milik pendaftar
+			user.Email_verified = true
+			if invitation.Org_id != "" {
+				user.Memberships = []models.Membership{{Org_id: invitation.Org_id, Role: invitation.Org_role, Joined_at: user.Created_at}}
+			}
+		}
+
+		// signup tidak membuat sesi; token diterbitkan saat user login
+		// keunikan email, phone dan user_id dijaga unique index, bukan dicek lebih dulu
+		insertErr := users.Create(ctx, user)
+		if insertErr != nil {
+			reopenInvitation(ctx, services, invitation)
+		}
+		var duplicate *repository.DuplicateError
+		if errors.As(insertErr, &duplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": duplicate.Error(), "field": duplicate.Field})
 			return
 		}
+		if insertErr != nil {
+			msg := fmt.Sprintf("User item was not created")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			return
+		}
+		// gagal kirim email tidak membatalkan signup, user bisa minta kirim ulang
+		if !user.Email_verified {
+			if err := sendEmailVerification(ctx, services, user, mail, verifyURL); err != nil {
+				log.Printf("Error sending verification email to user %s: %v", *user.User_id, err)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"InsertedID": user.ID})
+	}
+}
 
-		user.Paseto_token = &pasetoToken
+// reopenInvitation mengembalikan undangan yang sudah dipakai Signup ketika akunnya gagal dibuat
+func reopenInvitation(ctx context.Context, services *helper.Services, invitation *models.Invitation) {
+	if invitation == nil {
+		return
+	}
+	if err := services.Invitations.Reopen(ctx, invitation.Invitation_id); err != nil {
+		log.Printf("Error reopening invitation %s: %v", invitation.Invitation_id, err)
+	}
+}
 
-		// Generate token PASETO for public verification
-		publicPasetoToken, err := generatePublicPasetoToken(*user.User_id)
-		if err != nil {
-			log.Printf("Error generating public PASETO token: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate public PASETO token"})
+func Login(users repository.UserRepository, services *helper.Services, requireVerifiedEmail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var user struct {
+			Email    *string `json:"email"`
+			Password *string `json:"password"`
+		}
+
+		if err := c.BindJSON(&user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
 			return
 		}
 
-		user.PublicPaseto_token = &publicPasetoToken
+		// tolak lebih dulu jika email atau IP sedang dikunci karena terlalu sering gagal
+		retryAfter, err := services.LoginAttempts.Check(ctx, *user.Email, c.ClientIP())
+		if err != nil {
+			log.Printf("Error checking login attempts: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+			return
+		}
+		if retryAfter > 0 {
+			c.Header("Retry-After", helper.RetryAfterSeconds(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+			return
+		}
 
-		// Generate JWT secret key
-		jwtSecret, err := generateJWTKey()
+		foundUser, err := users.FindByEmail(ctx, *user.Email)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			// termasuk akun yang sudah dihapus
+			recordLoginFailure(ctx, services, *user.Email, c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "email or password is incorrect"})
+			return
+		}
 		if err != nil {
-			log.Printf("Error generating JWT key: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate JWT key"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
 			return
 		}
 
-		// Generate token JWT
-		tokenClaimsJWT := jwt.MapClaims{
-			"email":      *user.Email,
-			"first_name": *user.First_name,
-			"last_name":  *user.Last_name,
-			"uid":        *user.User_id,
-			"user_type":  *user.User_type,
+		passwordIsValid, msg := VerifyPassword(services, *user.Password, *foundUser.Password)
+		if !passwordIsValid {
+			recordLoginFailure(ctx, services, *user.Email, c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
 		}
+		upgradePasswordHash(ctx, users, services, &foundUser, *user.Password)
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaimsJWT)
-		jwtToken, err := token.SignedString(jwtSecret)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate JWT token"})
+		if foundUser.Email == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+			return
+		}
+		if requireVerifiedEmail && !foundUser.Email_verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "email is not verified"})
 			return
 		}
-		user.Token = &jwtToken
 
-		resultInsertNumber, insertErr := userCollection.InsertOne(ctx, user)
-		if insertErr != nil {
-			msg := fmt.Sprintf("User item was not created")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		// dengan TOTP aktif, password saja belum cukup: kembalikan tantangan untuk LoginMFA
+		if foundUser.Mfa_enabled {
+			mfaToken, err := services.IssueMFAChallenge(ctx, *foundUser.User_id)
+			if err != nil {
+				log.Printf("Error issuing MFA challenge: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": mfaToken, "expires_in": int(helper.MFAChallengeTTL.Seconds())})
 			return
 		}
-		defer cancel()
-		c.JSON(http.StatusOK, resultInsertNumber)
+
+		// hitungan gagal baru dihapus setelah login benar-benar selesai; dengan MFA hal ini dilakukan LoginMFA
+		recordLoginSuccess(ctx, services, *user.Email, c.ClientIP())
+		completeLogin(ctx, c, services, foundUser)
 	}
 }
 
-func Login() gin.HandlerFunc {
+// recordLoginFailure mencatat password salah; kegagalan mencatat tidak mengubah respons login
+func recordLoginFailure(ctx context.Context, services *helper.Services, email string, ip string) {
+	if err := services.LoginAttempts.Fail(ctx, email, ip); err != nil {
+		log.Printf("Error recording failed login: %v", err)
+	}
+}
+
+// recordLoginSuccess menghapus hitungan gagal email dan mengurangi hitungan gagal IP
+func recordLoginSuccess(ctx context.Context, services *helper.Services, email string, ip string) {
+	if err := services.LoginAttempts.Succeed(ctx, email, ip); err != nil {
+		log.Printf("Error clearing login attempts: %v", err)
+	}
+}
+
+// completeLogin membuat sesi baru dan menerbitkan semua token untuk user yang sudah lolos autentikasi.
+// Jika user hanya anggota satu organisasi, sesi langsung berada di organisasi itu.
+func completeLogin(ctx context.Context, c *gin.Context, services *helper.Services, foundUser models.User) {
+	startSession(ctx, c, services, foundUser, helper.DefaultOrganization(foundUser))
+}
+
+// startSession membuat sesi baru di organisasi orgId (boleh kosong), menerbitkan semua token lalu mengirimkan user
+func startSession(ctx context.Context, c *gin.Context, services *helper.Services, foundUser models.User, orgId string) {
+	session := helper.NewSession(*foundUser.User_id, c.Request.UserAgent(), c.ClientIP())
+	session.Org_id = orgId
+	tokens, err := issueSessionTokens(ctx, services, foundUser, session)
+	if err != nil {
+		log.Printf("Error generating tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	if err := services.InsertSession(ctx, session, tokens.Refresh_token); err != nil {
+		log.Printf("Error creating session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loginResponse{User: foundUser, sessionTokens: tokens})
+}
+
+// Refresh menukar refresh token yang masih berlaku dengan pasangan token baru (rotasi).
+// Refresh token yang sudah pernah dirotasi dianggap dicuri, sehingga sesinya diakhiri.
+func Refresh(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		var user models.User
-		var foundUser models.User
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Refresh_token string `json:"refresh_token" validate:"required"`
+		}
 
-		if err := c.BindJSON(&user); err != nil {
+		if err := c.BindJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
-		defer cancel()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred"})
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
-		defer cancel()
-		if !passwordIsValid {
+		claims, msg := services.ValidateToken(body.Refresh_token)
+		if msg != "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
 			return
 		}
+		if !claims.Refresh {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "not a refresh token"})
+			return
+		}
 
-		if foundUser.Email == nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		session, err := services.FindSession(ctx, claims.Session_id)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has been revoked"})
 			return
 		}
 
-		// Generate token PASETO for private use
-		pasetoToken, err := generatePasetoToken(*foundUser.User_id)
+		foundUser, err := users.FindByID(ctx, claims.Uid)
 		if err != nil {
-			log.Printf("Error generating PASETO token: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PASETO token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
 			return
 		}
 
-		foundUser.Paseto_token = &pasetoToken
+		// permission dan keanggotaan dibaca ulang, sehingga perubahan role berlaku sejak refresh berikutnya.
+		// Semua format access token diterbitkan ulang, sama seperti saat login.
+		tokens, err := issueSessionTokens(ctx, services, foundUser, session)
+		if err != nil {
+			log.Printf("Error generating tokens for user %s: %v", *foundUser.User_id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+			return
+		}
 
-		// Generate token PASETO for public verification
-		publicPasetoToken, err := generatePublicPasetoToken(*foundUser.User_id)
+		err = services.RotateSessionTokens(ctx, claims.Session_id, body.Refresh_token, tokens.Refresh_token)
+		if errors.Is(err, helper.ErrRefreshTokenReused) {
+			log.Printf("Refresh token reuse detected for user %s, session %s ended", *foundUser.User_id, claims.Session_id)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
-			log.Printf("Error generating public PASETO token: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate public PASETO token"})
+			log.Printf("Error rotating tokens: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate tokens"})
 			return
 		}
 
-		foundUser.PublicPaseto_token = &publicPasetoToken
+		c.JSON(http.StatusOK, tokens)
+	}
+}
 
-		//Generate token PASETO for publick verification and decrypt
-		// publicPasetoToken, err := generatePublicPasetoToken(*foundUser.User_id)
-		// if err != nil {
+// Logout mencabut access token yang sedang dipakai dan mengakhiri sesinya (refresh token ikut tidak berlaku)
+func Logout(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
 
-		// Generate JWT secret key for login
-		jwtSecret, err := generateJWTKey()
-		if err != nil {
-			log.Printf("Error generating JWT key: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate JWT key"})
+		if jti := c.GetString("jti"); jti != "" {
+			if err := services.Revocations.Revoke(ctx, jti, c.GetTime("token_expires_at")); err != nil {
+				log.Printf("Error revoking token: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+				return
+			}
+		}
+		err := services.DeleteSession(ctx, c.GetString("uid"), c.GetString("session_id"))
+		if err != nil && !errors.Is(err, helper.ErrSessionNotFound) {
+			log.Printf("Error ending session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
 			return
 		}
 
-		// Generate JWT token for login
-		tokenClaimsJWT := jwt.MapClaims{
-			"email":      *foundUser.Email,
-			"first_name": *foundUser.First_name,
-			"last_name":  *foundUser.Last_name,
-			"uid":        *foundUser.User_id,
-			"user_type":  *foundUser.User_type,
-		}
+		c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+	}
+}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaimsJWT)
-		jwtToken, err := token.SignedString(jwtSecret)
+// GetSessions menampilkan semua sesi (perangkat) aktif milik user yang sedang login
+func GetSessions(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		sessions, err := services.ListSessions(ctx, c.GetString("uid"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate JWT token"})
+			log.Printf("Error listing sessions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
 			return
 		}
-		foundUser.Token = &jwtToken
 
-		update := bson.M{
-			"$set": bson.M{"paseto_token": pasetoToken, "public_paseto_token": publicPasetoToken},
+		c.JSON(http.StatusOK, gin.H{"current_session_id": c.GetString("session_id"), "sessions": sessions})
+	}
+}
+
+// DeleteSession mengakhiri satu sesi milik user yang sedang login, misalnya perangkat yang hilang
+func DeleteSession(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		err := services.DeleteSession(ctx, c.GetString("uid"), c.Param("id"))
+		if errors.Is(err, helper.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error ending session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end session"})
+			return
 		}
 
-		filter := bson.M{"_id": foundUser.ID}
+		c.JSON(http.StatusOK, gin.H{"message": "session ended", "session_id": c.Param("id")})
+	}
+}
 
-		updateResult, err := userCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			log.Printf("Error updating PASETO token: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update PASETO token"})
+// RevokeSessions mencabut semua token milik user tertentu, butuh permission users:security
+func RevokeSessions(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.Param("user_id")
+		if err := services.RevokeUserSessions(ctx, userId); err != nil {
+			log.Printf("Error revoking sessions for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
 			return
 		}
 
-		// Check the number of documents updated
-		if updateResult.ModifiedCount != 1 {
-			log.Printf("No documents updated")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update PASETO token"})
+		c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked", "user_id": userId})
+	}
+}
+
+// RotateSigningKey membuat kunci JWT baru (atau kunci PASETO v2.public jika ?type=paseto);
+// kunci lama tetap valid selama masa tenggang
+func RotateSigningKey(services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		store := services.SigningKeys
+		if c.Query("type") == "paseto" {
+			store = services.PasetoKeys
+		}
+		key, err := store.Rotate(ctx)
+		if err != nil {
+			log.Printf("Error rotating signing key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate signing key"})
 			return
 		}
 
-		// Token updated successfully
-		c.JSON(http.StatusOK, foundUser)
+		c.JSON(http.StatusOK, key)
 	}
 }
 
-func GetUsers() gin.HandlerFunc {
+// GetPasetoPublicKeys mempublikasikan public key Ed25519 (beserta kid) untuk verifikasi token v2.public secara offline
+func GetPasetoPublicKeys(services *helper.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		helper.CheckUserType(c, "ADMIN")
-		err := helper.CheckUserType(c, "ADMIN")
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		keys, err := services.PasetoKeys.Keys(ctx)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			log.Printf("Error loading PASETO keys: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load PASETO keys"})
 			return
 		}
+		if len(keys) == 0 {
+			// pastikan selalu ada kunci aktif yang bisa dipublikasikan
+			key, err := services.PasetoKeys.Active(ctx)
+			if err != nil {
+				log.Printf("Error creating PASETO key: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load PASETO keys"})
+				return
+			}
+			keys = append(keys, key)
+		}
 
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		c.JSON(http.StatusOK, gin.H{"version": "v2", "purpose": "public", "keys": keys})
+	}
+}
+
+// GetUsers menampilkan semua user lintas organisasi, butuh permission global users:read
+func GetUsers(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
 		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
 		if err != nil || recordPerPage < 1 {
 			recordPerPage = 10
@@ -336,53 +634,296 @@ func GetUsers() gin.HandlerFunc {
 		}
 
 		startIndex := (page - 1) * recordPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-
-		matchStage := bson.D{{"$match", bson.D{{}}}}
-		groupStage := bson.D{{"$group", bson.D{{"_id", bson.D{{"_id", "null"}}}, {"total_count", bson.D{{"$sum", 1}}}, {"data", bson.D{{"$push", "$$ROOT"}}}}}}
-		projectStage := bson.D{
-			{
-				"project", bson.D{
-					{"_id", 0},
-					{"total_count", 1},
-					{"users", bson.D{{"$slice", []interface{}{"$data", startIndex, recordPerPage}}}},
-				},
-			},
-		}
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{matchStage, groupStage, projectStage})
-		defer cancel()
+		if index, err := strconv.Atoi(c.Query("startIndex")); err == nil && index >= 0 {
+			startIndex = index
+		}
+
+		allUsers, total, err := users.List(ctx, startIndex, recordPerPage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		var allUsers []bson.M
-		if err = result.All(ctx, &allUsers); err != nil {
-			log.Fatal(err)
+		c.JSON(http.StatusOK, gin.H{"total_count": total, "users": allUsers})
+	}
+}
+
+// GetUser menampilkan satu user; selain pemilik akun butuh permission global users:read yang berlaku lintas organisasi
+func GetUser(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.Param("user_id")
+
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		c.JSON(http.StatusOK, allUsers[0])
+		c.JSON(http.StatusOK, user)
 	}
 }
 
-func GetUser() gin.HandlerFunc {
+// userUpdate adalah field yang boleh diubah lewat PATCH /user/:user_id, field yang tidak dikirim tidak diubah
+type userUpdate struct {
+	First_name *string `json:"first_name"`
+	Last_name  *string `json:"last_name"`
+	Phone      *string `json:"phone"`
+	User_type  *string `json:"user_type"` // butuh permission roles:assign
+}
+
+// UpdateUser mengubah profil user: pemilik akun (atau yang punya users:write) boleh mengubah nama dan phone,
+// mengganti user_type (role) butuh permission roles:assign
+func UpdateUser(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userId := c.Param("id")
+		userId := c.Param("user_id")
 
-		if err := helper.MatchUserTypeToUid(c, userId); err != nil {
+		var update userUpdate
+		if err := c.BindJSON(&update); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if update.User_type != nil && !helper.HasPermission(c, models.PermissionRolesAssign) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + models.PermissionRolesAssign + " to change user_type"})
+			return
+		}
 
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		var user models.User
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
+		if update.User_type != nil && !validRole(ctx, c, services, "user_type", *update.User_type) {
+			return
+		}
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
+		previousType := *user.User_type
+		phoneChanged := false
+		if update.First_name != nil {
+			user.First_name = update.First_name
+		}
+		if update.Last_name != nil {
+			user.Last_name = update.Last_name
+		}
+		if update.Phone != nil {
+			normalized, err := phone.Normalize(*update.Phone, phone.DefaultRegion)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "phone"})
+				return
+			}
+			// nomor baru harus diverifikasi ulang
+			phoneChanged = user.Phone == nil || *user.Phone != normalized
+			if phoneChanged {
+				user.Phone_verified = false
+			}
+			user.Phone = &normalized
+		}
+		if update.User_type != nil {
+			user.User_type = update.User_type
+		}
+
+		// validasi ulang hasil gabungan dengan aturan yang sama seperti signup
+		if validateErr := validate.Struct(user); validateErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validateErr.Error()})
+			return
+		}
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		updateErr := users.Update(ctx, user)
+		var duplicate *repository.DuplicateError
+		if errors.As(updateErr, &duplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": duplicate.Error(), "field": duplicate.Field})
+			return
+		}
+		if updateErr != nil {
+			log.Printf("Error updating user %s: %v", userId, updateErr)
+			userUpdateError(c, updateErr, "user was not updated")
+			return
+		}
+
+		// kode OTP yang dikirim ke nomor lama tidak boleh memverifikasi nomor baru
+		if phoneChanged {
+			if err := services.UserTokens.DeleteByUser(ctx, userId, models.TokenPurposePhoneVerification); err != nil {
+				log.Printf("Error cancelling phone codes for user %s: %v", userId, err)
+			}
+		}
+
+		// user_type ikut tercantum di token, jadi token lama harus dicabut agar perubahan peran langsung berlaku
+		if *user.User_type != previousType {
+			if err := services.RevokeUserSessions(ctx, userId); err != nil {
+				log.Printf("Error revoking sessions for user %s: %v", userId, err)
+			}
+		}
+
 		c.JSON(http.StatusOK, user)
 	}
 }
+
+// DeleteUser menghapus akun (soft delete); akun masih bisa dipulihkan (permission users:delete) selama masa tenggang
+func DeleteUser(users repository.UserRepository, services *helper.Services, grace time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.Param("user_id")
+
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		deletedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := users.SoftDelete(ctx, userId, deletedAt)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error deleting user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user was not deleted"})
+			return
+		}
+
+		// akun yang dihapus tidak boleh lagi memakai token yang sudah terbit
+		if err := services.RevokeUserSessions(ctx, userId); err != nil {
+			log.Printf("Error revoking sessions for user %s: %v", userId, err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user deleted", "user_id": userId, "deleted_at": deletedAt, "purge_at": deletedAt.Add(grace)})
+	}
+}
+
+// RestoreUser membatalkan penghapusan akun selama masa tenggang belum lewat, butuh permission users:delete
+func RestoreUser(users repository.UserRepository, grace time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.Param("user_id")
+		err := users.Restore(ctx, userId, time.Now().Add(-grace))
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no deleted user within the grace period"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error restoring user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "user was not restored"})
+			return
+		}
+
+		user, err := users.FindByID(ctx, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// ChangePassword mengganti password user yang sedang login setelah password lama dicek,
+// lalu mengakhiri semua sesi lain dan mencabut semua API key milik user tersebut
+func ChangePassword(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		var body struct {
+			Current_password string `json:"current_password" validate:"required"`
+			New_password     string `json:"new_password" validate:"required"`
+		}
+
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userId := c.GetString("uid")
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if passwordIsValid, msg := VerifyPassword(services, body.Current_password, *user.Password); !passwordIsValid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+		if body.New_password == body.Current_password {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "new password must differ from the current password"})
+			return
+		}
+
+		if err := services.CheckPassword(user, body.New_password); err != nil {
+			passwordError(c, err)
+			return
+		}
+		if err := services.CheckPasswordHistory(user, body.New_password); err != nil {
+			passwordError(c, err)
+			return
+		}
+		password, err := HashPassword(services, body.New_password)
+		if err != nil {
+			log.Printf("Error hashing password for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password was not changed"})
+			return
+		}
+		services.SetPassword(&user, password)
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		if err := users.Update(ctx, user); err != nil {
+			log.Printf("Error updating password for user %s: %v", userId, err)
+			userUpdateError(c, err, "password was not changed")
+			return
+		}
+
+		if err := services.DeleteOtherSessions(ctx, userId, c.GetString("session_id")); err != nil {
+			log.Printf("Error ending other sessions for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed but other sessions could not be ended"})
+			return
+		}
+		if err := services.RevokeUserAPIKeys(ctx, userId); err != nil {
+			log.Printf("Error revoking API keys for user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed but API keys could not be revoked"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+	}
+}
+
+// UnlockUser menghapus kunci login akibat password salah berulang untuk user tertentu, butuh permission users:security
+func UnlockUser(users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+		userId := c.Param("user_id")
+		user, err := users.FindByID(ctx, userId)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := services.LoginAttempts.Unlock(ctx, *user.Email); err != nil {
+			log.Printf("Error unlocking user %s: %v", userId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user unlocked", "user_id": userId})
+	}
+}
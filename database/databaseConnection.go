@@ -2,40 +2,39 @@ package database
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"os"
 	"time"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // connect to database mongo db
-func DBinstance() *mongo.Client {
-	err := godotenv.Load(".env") // mengambil env
+func DBinstance(ctx context.Context, mongoURL string) (*mongo.Client, error) {
+	client, err := mongo.NewClient(options.Client().ApplyURI(mongoURL)) // membuat client baru
 	if err != nil {
-		log.Fatal("Error loading .env file")
-	} // jika error maka akan memunculkan error loading .env file
-	MongoDB := os.Getenv("MONGO_URL")                                  // mengambil env mongo db
-	client, err := mongo.NewClient(options.Client().ApplyURI(MongoDB)) // membuat client baru
-	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second) // membuat context baru
-	defer cancel()                                                           // defer cancel
-	err = client.Connect(ctx)
-	if err != nil {
-		log.Fatal(err)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second) // membuat context baru
+	defer cancel()                                          // defer cancel
+	if err := client.Connect(ctx); err != nil {
+		return nil, err
 	} // menghubungkan client dengan context
-	fmt.Println("Connected to MongoDB!") // memunculkan connected to mongo db
-	return client                        // mengembalikan client
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	} // memastikan server benar-benar bisa dijangkau
+	return client, nil // mengembalikan client
 } // mengembalikan client
 
-var Client *mongo.Client = DBinstance() // membuat client baru
+// DatabaseName adalah nama database MongoDB yang dipakai service
+const DatabaseName = "golangjwt"
+
+// OpenDatabase mengembalikan database service, dipakai misalnya oleh migrasi
+func OpenDatabase(client *mongo.Client) *mongo.Database {
+	return client.Database(DatabaseName)
+}
 
 func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	var collection *mongo.Collection = client.Database("golangjwt").Collection(collectionName) // membuat collection baru
-	return collection                                                                          // mengembalikan collection
+	var collection *mongo.Collection = OpenDatabase(client).Collection(collectionName) // membuat collection baru
+	return collection                                                                  // mengembalikan collection
 }
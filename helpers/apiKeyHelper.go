@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyPrefix mengawali setiap API key supaya mudah dikenali (mis. oleh secret scanner)
+const APIKeyPrefix = "gsk_"
+
+// ErrInvalidAPIKey dikembalikan ketika API key tidak dikenal, salah, dicabut atau kedaluwarsa
+var ErrInvalidAPIKey = errors.New("invalid or expired API key")
+
+// NewAPIKey membuat API key baru berbentuk gsk_<prefix>_<secret> beserta prefix untuk pencarian dan hash untuk disimpan
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 38)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(b[:6])
+	key = APIKeyPrefix + prefix + "_" + hex.EncodeToString(b[6:])
+	return key, prefix, hashUserToken(key), nil
+}
+
+// VerifyAPIKey mencari API key dari prefix-nya lalu membandingkan hash-nya
+func (s *Services) VerifyAPIKey(ctx context.Context, key string) (models.APIKey, error) {
+	parts := strings.Split(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !strings.HasPrefix(key, APIKeyPrefix) || len(parts) != 2 || parts[0] == "" {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	found, err := s.APIKeys.FindByPrefix(ctx, parts[0])
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(found.Key_hash), []byte(hashUserToken(key))) != 1 {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if found.Revoked_at != nil || (found.Expires_at != nil && !found.Expires_at.After(time.Now())) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	return found, nil
+}
+
+// APIKeyPermissions mengembalikan scope API key yang masih dimiliki role pemiliknya saat ini,
+// sehingga key tidak pernah lebih kuat dari user-nya walaupun role-nya diturunkan
+func (s *Services) APIKeyPermissions(ctx context.Context, key models.APIKey, role string) ([]string, error) {
+	granted, err := s.PermissionsFor(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	permissions := []string{}
+	for _, scope := range key.Scopes {
+		for _, permission := range granted {
+			if scope == permission {
+				permissions = append(permissions, scope)
+				break
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// RevokeUserAPIKeys mencabut semua API key milik user. API key tidak terikat sesi,
+// jadi harus dicabut tersendiri setiap kali semua akses lama user diputus (mis. ganti password).
+func (s *Services) RevokeUserAPIKeys(ctx context.Context, userId string) error {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return s.APIKeys.RevokeAllByUser(ctx, userId, now)
+}
+
+// TouchAPIKey mencatat waktu terakhir API key dipakai, paling sering sekali per lastSeenInterval
+func (s *Services) TouchAPIKey(ctx context.Context, key models.APIKey) error {
+	if key.Last_used_at != nil && time.Since(*key.Last_used_at) < lastSeenInterval {
+		return nil
+	}
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return s.APIKeys.UpdateLastUsed(ctx, key.Key_id, now)
+}
+
+// IsAPIKey mengecek apakah request diautentikasi dengan API key, bukan token sesi
+func IsAPIKey(c *gin.Context) bool {
+	return c.GetString("api_key_id") != ""
+}
@@ -1,29 +1,47 @@
 package helpers
 
 import (
+	"context"
 	"errors"
+	"golangsidang/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CheckUserType(c *gin.Context, role string) (err error) { // membuat fungsi CheckUserType
-	userType := c.GetString("user_type")
-	err = nil
-	if userType != role {
-		err = errors.New("Unauthorized")
-		return err // jika userType tidak sama dengan role
+// PermissionsFor mengembalikan permission dari role untuk dicantumkan di token.
+// Role yang sudah dihapus tidak memberi permission apa pun.
+func (s *Services) PermissionsFor(ctx context.Context, role string) ([]string, error) {
+	found, err := s.Roles.FindByName(ctx, role)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return []string{}, nil
 	}
-	return err // mengambil user_type dari context
+	if err != nil {
+		return nil, err
+	}
+	return found.Permissions, nil
+}
+
+// HasPermission mengecek permission dari token yang sudah diverifikasi middleware Authenticate
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, granted := range c.GetStringSlice("permissions") {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
 }
-func MatchUserTypeToUid(c *gin.Context, userId string) (err error) { // membuat fungsi MatchUserTypeToUid
-	userType := c.GetString("user_type") // mengambil uid dari context
+
+// IsSelf mengecek apakah userId adalah user pemilik token
+func IsSelf(c *gin.Context, userId string) bool {
 	uid := c.GetString("uid")
+	return uid != "" && uid == userId
+}
 
-	err = nil
-	if userType == "USER" && uid != userId {
-		err = errors.New("Unauthorized") // jika uid tidak sama dengan userId
-		return err
+// ValidRole mengecek apakah role ada di RoleRepository, dipakai untuk memvalidasi User_type
+func (s *Services) ValidRole(ctx context.Context, role string) (bool, error) {
+	_, err := s.Roles.FindByName(ctx, role)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return false, nil
 	}
-	CheckUserType(c, userType)
-	return err // jika uid tidak sama dengan userId
+	return err == nil, err
 }
@@ -0,0 +1,22 @@
+package helpers
+
+// MaxBackgroundJobs membatasi jumlah pekerjaan background (mis. kirim email reset password) yang berjalan bersamaan
+const MaxBackgroundJobs = 32
+
+var backgroundJobs = make(chan struct{}, MaxBackgroundJobs)
+
+// RunInBackground menjalankan job di goroutine baru jika masih ada slot.
+// Jika semua slot terpakai, job tidak dijalankan dan false dikembalikan, supaya banjir request
+// tidak membuat goroutine dan koneksi SMTP tanpa batas.
+func RunInBackground(job func()) bool {
+	select {
+	case backgroundJobs <- struct{}{}:
+	default:
+		return false
+	}
+	go func() {
+		defer func() { <-backgroundJobs }()
+		job()
+	}()
+	return true
+}
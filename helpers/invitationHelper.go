@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"time"
+)
+
+// MaxInvitationTTL adalah batas umur undangan yang boleh diminta admin
+const MaxInvitationTTL = 30 * 24 * time.Hour
+
+// ErrInvalidInvitation dikembalikan ketika token undangan tidak dikenal, sudah dipakai, dicabut atau kedaluwarsa
+var ErrInvalidInvitation = errors.New("invitation is invalid, expired or already used")
+
+// IssueInvitation membuat token untuk undangan lalu menyimpannya; token asli hanya dikembalikan ke pemanggil
+func (s *Services) IssueInvitation(ctx context.Context, invitation *models.Invitation) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	invitation.Token_hash = hashUserToken(token)
+	if err := s.Invitations.Create(ctx, *invitation); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// FindInvitation mengembalikan undangan yang masih bisa dipakai untuk token tersebut, tanpa memakainya
+func (s *Services) FindInvitation(ctx context.Context, token string) (models.Invitation, error) {
+	invitation, err := s.Invitations.FindPending(ctx, hashUserToken(token), time.Now())
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return models.Invitation{}, ErrInvalidInvitation
+	}
+	return invitation, err
+}
+
+// AcceptInvitation memakai undangan untuk akun userId; gagal dengan ErrInvalidInvitation jika sudah didahului permintaan lain
+func (s *Services) AcceptInvitation(ctx context.Context, token string, userId string) (models.Invitation, error) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	invitation, err := s.Invitations.Accept(ctx, hashUserToken(token), userId, now)
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return models.Invitation{}, ErrInvalidInvitation
+	}
+	return invitation, err
+}
@@ -0,0 +1,12 @@
+package helpers
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// RetryAfterSeconds membulatkan sisa waktu kunci ke atas untuk header Retry-After
+func RetryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"golangsidang/models"
+	"strings"
+	"time"
+)
+
+// Aturan two-factor authentication
+const (
+	MFAChallengeTTL   = 5 * time.Minute
+	RecoveryCodeCount = 10
+	MFAIssuer         = "golangsidang"
+)
+
+// IssueMFAChallenge membuat token tantangan MFA setelah password benar; token ditukar dengan kode TOTP di LoginMFA
+func (s *Services) IssueMFAChallenge(ctx context.Context, userId string) (string, error) {
+	return s.IssueUserToken(ctx, userId, models.TokenPurposeMFAChallenge, MFAChallengeTTL)
+}
+
+// ConsumeMFAChallenge memakai token tantangan MFA. Karena token langsung dihapus,
+// tebakan paralel dengan token yang sama tidak mungkin; RetryMFAChallenge memulihkannya jika kode salah.
+func (s *Services) ConsumeMFAChallenge(ctx context.Context, token string) (models.UserToken, error) {
+	return s.TakeUserToken(ctx, models.TokenPurposeMFAChallenge, token)
+}
+
+// RetryMFAChallenge menyimpan kembali tantangan dengan satu percobaan gagal tambahan.
+// Setelah MaxOTPAttempts kali salah, ErrTooManyOTPAttempts dikembalikan dan user harus login ulang.
+func (s *Services) RetryMFAChallenge(ctx context.Context, challenge models.UserToken) error {
+	challenge.Attempts++
+	if challenge.Attempts >= MaxOTPAttempts {
+		return ErrTooManyOTPAttempts
+	}
+	return s.UserTokens.Create(ctx, challenge)
+}
+
+// NewRecoveryCodes membuat recovery code acak (format xxxxx-xxxxx) beserta hash yang disimpan di user
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashUserToken(code))
+	}
+	return codes, hashes, nil
+}
+
+// UseRecoveryCode menghapus recovery code yang cocok dari user; false jika tidak ada yang cocok
+func UseRecoveryCode(user *models.User, code string) bool {
+	hash := hashUserToken(strings.ToLower(strings.TrimSpace(code)))
+	for i, stored := range user.Mfa_recovery_codes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			user.Mfa_recovery_codes = append(user.Mfa_recovery_codes[:i:i], user.Mfa_recovery_codes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"golangsidang/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MembershipOf mengembalikan keanggotaan user di organisasi orgId
+func MembershipOf(user models.User, orgId string) (models.Membership, bool) {
+	for _, membership := range user.Memberships {
+		if membership.Org_id == orgId {
+			return membership, true
+		}
+	}
+	return models.Membership{}, false
+}
+
+// DefaultOrganization adalah organisasi aktif untuk sesi baru: satu-satunya organisasi user,
+// atau kosong jika user belum atau lebih dari satu organisasi (pilih lewat switch organisasi)
+func DefaultOrganization(user models.User) string {
+	if len(user.Memberships) == 1 {
+		return user.Memberships[0].Org_id
+	}
+	return ""
+}
+
+// HasOrgPermission mengecek permission di organisasi aktif dari token (claim org_permissions)
+func HasOrgPermission(c *gin.Context, permission string) bool {
+	if c.GetString("tenant_id") == "" {
+		return false
+	}
+	for _, granted := range c.GetStringSlice("org_permissions") {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
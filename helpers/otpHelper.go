@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"golangsidang/repository"
+	"math/big"
+	"time"
+)
+
+// Aturan kode OTP SMS
+const (
+	OTPTTL         = 10 * time.Minute
+	MaxOTPAttempts = 5
+)
+
+// ErrTooManyOTPAttempts dikembalikan ketika kode salah terlalu sering; kode dibatalkan dan harus diminta ulang
+var ErrTooManyOTPAttempts = errors.New("too many invalid codes, request a new one")
+
+// IssueOTP membuat kode 6 digit untuk user; kode lama dengan purpose yang sama ikut dibatalkan
+func (s *Services) IssueOTP(ctx context.Context, userId string, purpose string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	if err := s.storeUserToken(ctx, userId, purpose, otpHash(userId, code), OTPTTL); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// VerifyOTP memakai kode OTP milik user. Setelah MaxOTPAttempts kali salah, kode dibatalkan.
+// Percobaan dicatat sebelum kode dibandingkan, sehingga tebakan paralel tetap dibatasi MaxOTPAttempts.
+func (s *Services) VerifyOTP(ctx context.Context, userId string, purpose string, code string) error {
+	attempts, err := s.UserTokens.AddFailedAttempt(ctx, userId, purpose)
+	if errors.Is(err, repository.ErrUserTokenNotFound) {
+		return ErrInvalidUserToken
+	}
+	if err != nil {
+		return err
+	}
+	if attempts > MaxOTPAttempts {
+		if err := s.UserTokens.DeleteByUser(ctx, userId, purpose); err != nil {
+			return err
+		}
+		return ErrTooManyOTPAttempts
+	}
+
+	_, err = s.UserTokens.Consume(ctx, purpose, otpHash(userId, code), time.Now())
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrUserTokenNotFound) {
+		return err
+	}
+	if attempts == MaxOTPAttempts {
+		if err := s.UserTokens.DeleteByUser(ctx, userId, purpose); err != nil {
+			return err
+		}
+		return ErrTooManyOTPAttempts
+	}
+	return ErrInvalidUserToken
+}
+
+// otpHash mengikat kode ke user, karena kode 6 digit saja bisa sama antar user
+func otpHash(userId string, code string) string {
+	return hashUserToken(userId + ":" + code)
+}
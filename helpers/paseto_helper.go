@@ -1,21 +1,34 @@
 package helpers
 
 import (
-	"github.com/o1egl/paseto"
-)
+	"context"
+	"errors"
+	"time"
 
-// VerifyToken digunakan untuk memverifikasi token PASETO
-func VerifyToken(token string) (Claims, error) {
-	var claims Claims
+	"github.com/o1egl/paseto/v2"
+)
 
-	// Buat dekoder PASETO
-	v2 := paseto.NewV2()
+// VerifyToken digunakan untuk memverifikasi token PASETO v2.local
+func (s *Services) VerifyToken(token string) (Claims, error) {
+	var footer PasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return Claims{}, err
+	}
+	if footer.Kid == "" {
+		return Claims{}, errors.New("token has no kid footer")
+	}
 
-	// Verifikasi token
-	err := v2.Decrypt(token, secretKey, &claims, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key, err := s.PasetoLocalKeys.Lookup(ctx, footer.Kid)
 	if err != nil {
 		return Claims{}, err
 	}
 
-	return claims, nil
+	// Verifikasi dan dekripsi token
+	var jsonToken paseto.JSONToken
+	if err := paseto.NewV2().Decrypt(token, key.Secret, &jsonToken, nil); err != nil {
+		return Claims{}, err
+	}
+	return claimsFromJSONToken(jsonToken)
 }
@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"time"
+
+	"github.com/o1egl/paseto/v2"
+)
+
+// PasetoFooter dicantumkan (tidak terenkripsi) di token v2.public agar verifier tahu kunci mana yang dipakai
+type PasetoFooter struct {
+	Kid string `json:"kid"`
+}
+
+// GeneratePublicPasetoToken menandatangani claims sebagai token PASETO v2.public dengan kunci aktif
+func (s *Services) GeneratePublicPasetoToken(claims Claims, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key, err := s.PasetoKeys.Active(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	jsonToken, err := newJSONToken(claims, ttl)
+	if err != nil {
+		return "", err
+	}
+	return paseto.NewV2().Sign(ed25519.PrivateKey(key.Secret), jsonToken, PasetoFooter{Kid: key.Kid})
+}
+
+// VerifyPublicPasetoToken memverifikasi token v2.public dengan kunci dari PasetoKeys berdasarkan kid di footer
+func (s *Services) VerifyPublicPasetoToken(token string) (Claims, error) {
+	var footer PasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return Claims{}, err
+	}
+	if footer.Kid == "" {
+		return Claims{}, errors.New("token has no kid footer")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key, err := s.PasetoKeys.Lookup(ctx, footer.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	return VerifyPublicPasetoTokenWithKey(token, ed25519.PublicKey(key.Public_key))
+}
+
+// VerifyPublicPasetoTokenWithKey memverifikasi token v2.public hanya dengan public key,
+// tanpa akses ke database. Ini yang dipakai service lain setelah mengambil GET /keys/paseto.
+func VerifyPublicPasetoTokenWithKey(token string, publicKey ed25519.PublicKey) (Claims, error) {
+	var jsonToken paseto.JSONToken
+	if err := paseto.NewV2().Verify(token, publicKey, &jsonToken, nil); err != nil {
+		return Claims{}, err
+	}
+	return claimsFromJSONToken(jsonToken)
+}
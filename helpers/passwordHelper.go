@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"errors"
+	"golangsidang/models"
+)
+
+// ErrPasswordReused dikembalikan jika password baru sama dengan salah satu password terakhir user
+var ErrPasswordReused = errors.New("password was used recently, choose a different one")
+
+// CheckPassword menerapkan PasswordPolicy pada password baru, termasuk larangan memuat email atau nama user
+func (s *Services) CheckPassword(user models.User, password string) error {
+	var personal []string
+	for _, value := range []*string{user.Email, user.First_name, user.Last_name} {
+		if value != nil {
+			personal = append(personal, *value)
+		}
+	}
+	return s.PasswordPolicy.Check(password, personal...)
+}
+
+// CheckPasswordHistory menolak password yang sama dengan password sekarang atau riwayatnya,
+// sebanyak PasswordPolicy.History password terakhir
+func (s *Services) CheckPasswordHistory(user models.User, password string) error {
+	var hashes []string
+	if user.Password != nil {
+		hashes = append(hashes, *user.Password)
+	}
+	hashes = append(hashes, user.Password_history...)
+	for i, hash := range hashes {
+		if i >= s.PasswordPolicy.History {
+			break
+		}
+		if s.Passwords.Verify(hash, password) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// SetPassword mengganti hash password user dan menyimpan hash lama ke riwayat
+func (s *Services) SetPassword(user *models.User, hash string) {
+	if user.Password != nil && s.PasswordPolicy.History > 1 {
+		history := append([]string{*user.Password}, user.Password_history...)
+		if len(history) > s.PasswordPolicy.History-1 {
+			history = history[:s.PasswordPolicy.History-1]
+		}
+		user.Password_history = history
+	} else {
+		user.Password_history = nil
+	}
+	user.Password = &hash
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"errors"
+	"golangsidang/models"
+	"golangsidang/passhash"
+	"golangsidang/passpolicy"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHistory(t *testing.T) {
+	policy := passpolicy.Default()
+	policy.History = 3
+	services := &Services{Passwords: passhash.New(passhash.Bcrypt{Cost: bcrypt.MinCost}), PasswordPolicy: policy}
+
+	var user models.User
+	for _, password := range []string{"First-pass1", "Second-pass2", "Third-pass3", "Fourth-pass4"} {
+		hash, err := services.Passwords.Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		services.SetPassword(&user, hash)
+	}
+	// History menghitung password sekarang, jadi riwayat hanya menyimpan History-1 hash lama
+	if len(user.Password_history) != 2 {
+		t.Fatalf("history has %d hashes, want 2", len(user.Password_history))
+	}
+
+	for _, password := range []string{"Fourth-pass4", "Third-pass3", "Second-pass2"} {
+		if err := services.CheckPasswordHistory(user, password); !errors.Is(err, ErrPasswordReused) {
+			t.Errorf("CheckPasswordHistory(%q) = %v, want ErrPasswordReused", password, err)
+		}
+	}
+	for _, password := range []string{"First-pass1", "Fifth-pass5"} {
+		if err := services.CheckPasswordHistory(user, password); err != nil {
+			t.Errorf("CheckPasswordHistory(%q) = %v, want nil", password, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"context"
+	"time"
+)
+
+// RevokeUserSessions mencabut semua token dan API key milik user yang sudah terbit dan mengakhiri semua sesinya
+func (s *Services) RevokeUserSessions(ctx context.Context, userId string) error {
+	now := time.Now()
+	// penanda disimpan selama umur token terpanjang, setelah itu semua token lama sudah kedaluwarsa
+	if err := s.Revocations.RevokeUser(ctx, userId, now, now.Add(RefreshTokenTTL)); err != nil {
+		return err
+	}
+	if err := s.RevokeUserAPIKeys(ctx, userId); err != nil {
+		return err
+	}
+	return s.DeleteUserSessions(ctx, userId)
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"golangsidang/keystore"
+	"golangsidang/lockout"
+	"golangsidang/passhash"
+	"golangsidang/passpolicy"
+	"golangsidang/repository"
+	"golangsidang/revocation"
+)
+
+// Services adalah penyimpanan dan aturan yang dipakai helper untuk menerbitkan dan memverifikasi token,
+// mengelola sesi dan seterusnya. Dirakit sekali saat aplikasi dibuat (lihat package app), lalu diteruskan
+// ke routes, controllers dan middleware bersama UserRepository.
+type Services struct {
+	// SigningKeys dipakai bersama oleh penerbitan JWT dan ValidateToken
+	SigningKeys *keystore.Store
+	// PasetoKeys menyimpan pasangan kunci Ed25519 untuk token PASETO v2.public.
+	// Public key-nya dipublikasikan lewat GET /keys/paseto sehingga service lain bisa memverifikasi secara offline.
+	PasetoKeys *keystore.Store
+	// PasetoLocalKeys menyimpan kunci simetris untuk token PASETO v2.local
+	PasetoLocalKeys *keystore.Store
+	Roles           repository.RoleRepository
+	Organizations   repository.OrganizationRepository
+	Invitations     repository.InvitationRepository
+	APIKeys         repository.APIKeyRepository
+	Sessions        repository.SessionRepository
+	// UserTokens menyimpan token sekali pakai (reset password, verifikasi email, OTP, tantangan MFA)
+	UserTokens repository.UserTokenRepository
+	// Revocations adalah deny-list token yang dicek middleware.Authenticate di setiap request
+	Revocations revocation.Store
+	// Passwords membuat dan memeriksa hash password
+	Passwords *passhash.PasswordHasher
+	// PasswordPolicy adalah aturan password baru
+	PasswordPolicy passpolicy.Policy
+	// LoginAttempts melacak login gagal per email dan per IP
+	LoginAttempts *lockout.Guard
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lastSeenInterval membatasi seberapa sering last_seen_at ditulis ke database
+const lastSeenInterval = time.Minute
+
+var (
+	// ErrRefreshTokenReused dikembalikan ketika refresh token yang sudah dirotasi dipakai lagi
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionNotFound dikembalikan ketika sesi sudah diakhiri atau kedaluwarsa
+	ErrSessionNotFound = repository.ErrSessionNotFound
+)
+
+// NewSession menyiapkan sesi baru (belum disimpan) agar session id bisa dimasukkan ke token terlebih dahulu
+func NewSession(userId string, userAgent string, ip string) models.Session {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	id := primitive.NewObjectID()
+	return models.Session{
+		ID:           id,
+		Session_id:   id.Hex(),
+		User_id:      userId,
+		User_agent:   userAgent,
+		Ip:           ip,
+		Created_at:   now,
+		Last_seen_at: now,
+		Expires_at:   now.Add(RefreshTokenTTL),
+	}
+}
+
+// InsertSession menyimpan sesi beserta hash refresh token pertamanya; refresh token sendiri tidak disimpan
+func (s *Services) InsertSession(ctx context.Context, session models.Session, refreshToken string) error {
+	session.Refresh_token_hash = hashUserToken(refreshToken)
+	return s.Sessions.Create(ctx, session)
+}
+
+// FindSession mengembalikan sesi yang masih aktif
+func (s *Services) FindSession(ctx context.Context, sessionId string) (models.Session, error) {
+	session, err := s.Sessions.FindByID(ctx, sessionId)
+	if err != nil {
+		return models.Session{}, err
+	}
+	// TTL monitor MongoDB tidak langsung menghapus dokumen, jadi kedaluwarsa tetap dicek di sini
+	if time.Now().After(session.Expires_at) {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// TouchSession memperbarui last_seen_at, paling sering sekali per lastSeenInterval
+func (s *Services) TouchSession(ctx context.Context, session models.Session) error {
+	if time.Since(session.Last_seen_at) < lastSeenInterval {
+		return nil
+	}
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return s.Sessions.UpdateLastSeen(ctx, session.Session_id, now)
+}
+
+// RotateSessionTokens mengganti refresh token sesi hanya jika refresh token lama masih yang tersimpan.
+// Jika refresh token lama sudah dirotasi sebelumnya, sesi diakhiri dan ErrRefreshTokenReused dikembalikan.
+func (s *Services) RotateSessionTokens(ctx context.Context, sessionId string, oldRefreshToken string, newRefreshToken string) error {
+	session, err := s.Sessions.FindByID(ctx, sessionId)
+	if errors.Is(err, ErrSessionNotFound) {
+		return ErrRefreshTokenReused
+	}
+	if err != nil {
+		return err
+	}
+
+	oldHash := hashUserToken(oldRefreshToken)
+	if subtle.ConstantTimeCompare([]byte(session.Refresh_token_hash), []byte(oldHash)) == 1 {
+		// penggantian tetap bersyarat pada hash lama, sehingga dua refresh bersamaan tidak sama-sama berhasil
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		replaced, err := s.Sessions.ReplaceRefreshToken(ctx, sessionId, oldHash, hashUserToken(newRefreshToken), now, now.Add(RefreshTokenTTL))
+		if err != nil {
+			return err
+		}
+		if replaced {
+			return nil
+		}
+	}
+
+	if err := s.Sessions.Delete(ctx, session.User_id, sessionId); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// ListSessions mengembalikan sesi aktif milik user, yang terbaru dipakai lebih dulu
+func (s *Services) ListSessions(ctx context.Context, userId string) ([]models.Session, error) {
+	return s.Sessions.ListByUser(ctx, userId, time.Now())
+}
+
+// DeleteSession mengakhiri satu sesi milik user; ErrSessionNotFound jika sesi bukan miliknya
+func (s *Services) DeleteSession(ctx context.Context, userId string, sessionId string) error {
+	return s.Sessions.Delete(ctx, userId, sessionId)
+}
+
+// DeleteOtherSessions mengakhiri semua sesi milik user kecuali sesi keepSessionId.
+// Access token ikut tidak berlaku karena middleware.Authenticate mensyaratkan sesinya masih ada.
+func (s *Services) DeleteOtherSessions(ctx context.Context, userId string, keepSessionId string) error {
+	sessions, err := s.ListSessions(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.Session_id == keepSessionId {
+			continue
+		}
+		if err := s.Sessions.Delete(ctx, userId, session.Session_id); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteUserSessions mengakhiri semua sesi milik user
+func (s *Services) DeleteUserSessions(ctx context.Context, userId string) error {
+	return s.Sessions.DeleteByUser(ctx, userId)
+}
+
+// EndOrganizationSessions mengakhiri sesi user yang sedang berada di organisasi orgId,
+// dipakai ketika keanggotaan atau role-nya di organisasi itu berubah
+func (s *Services) EndOrganizationSessions(ctx context.Context, userId string, orgId string) error {
+	sessions, err := s.ListSessions(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.Org_id != orgId {
+			continue
+		}
+		if err := s.Sessions.Delete(ctx, userId, session.Session_id); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
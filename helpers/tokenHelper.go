@@ -2,64 +2,126 @@ package helpers
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"golangsidang/database"
-	"log"
-	"os"
+	"golangsidang/models"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SignedDetails struct {
-	Email      string
-	First_name string
-	Last_name  string
-	Uid        string
-	User_type  string
+	Email       string
+	First_name  string
+	Last_name   string
+	Uid         string
+	User_type   string
+	Session_id  string   // sesi (perangkat) tempat token diterbitkan
+	Refresh     bool     // true untuk refresh token, tidak boleh dipakai sebagai access token
+	Permissions []string // permission dari role user, lihat models.Role
+	// Tid adalah organisasi aktif sesi, Org_permissions permission role keanggotaan di organisasi itu
+	Tid             string
+	Org_permissions []string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user") // create a new collection
+// Umur access token dan refresh token
+const (
+	AccessTokenTTL  = time.Hour * time.Duration(24)
+	RefreshTokenTTL = time.Hour * time.Duration(168)
+)
 
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+// NewTokenID membuat id acak untuk claim jti
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-func GenerateAllTokens(email string, firstName string, lastName string, userType string, uid string) (signedToken string, signedRefreshToken string, err error) {
+// GenerateAllTokens menerbitkan access token dan refresh token JWT untuk user pada claims (lihat Claims)
+func (s *Services) GenerateAllTokens(user Claims) (signedToken string, signedRefreshToken string, err error) {
+	jti, err := NewTokenID() // id unik untuk tiap token, dipakai oleh deny-list
+	if err != nil {
+		return "", "", err
+	}
+	refreshJti, err := NewTokenID()
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now().Local()
 	claims := &SignedDetails{
-		Email:      email,
-		First_name: firstName,
-		Last_name:  lastName,
-		Uid:        uid,
-		User_type:  userType,
+		Email:           user.Email,
+		First_name:      user.FirstName,
+		Last_name:       user.LastName,
+		Uid:             user.Uid,
+		User_type:       user.UserType,
+		Permissions:     user.Permissions,
+		Tid:             user.Tid,
+		Org_permissions: user.OrgPermissions,
+		Session_id:      user.Sid,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
 		},
 	}
 	refreshClaims := &SignedDetails{
+		Uid:        user.Uid,
+		Session_id: user.Sid,
+		Refresh:    true,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(168)).Unix(),
+			Id:        refreshJti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(RefreshTokenTTL).Unix(),
 		},
 	}
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
-
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key, err := s.SigningKeys.Active(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := signWithKey(claims, key)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := signWithKey(refreshClaims, key)
 	if err != nil {
-		log.Panic(err)
 		return "", "", err
 	}
-	return token, refreshToken, err
+	return token, refreshToken, nil
+}
+
+// signWithKey menandatangani claims dengan kunci dari keystore dan mencantumkan kid pada header
+func signWithKey(claims jwt.Claims, key models.SigningKey) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Secret)
 }
 
-func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
+func (s *Services) ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&SignedDetails{},
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(SECRET_KEY), nil
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+			}
+			kid, _ := token.Header["kid"].(string)
+			if kid == "" {
+				return nil, errors.New("token has no kid header")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			key, err := s.SigningKeys.Lookup(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			return key.Secret, nil
 		},
 	)
 	if err != nil {
@@ -68,41 +130,12 @@ func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 	}
 	claims, ok := token.Claims.(*SignedDetails)
 	if !ok {
-		msg = fmt.Sprintf("the token is invalid")
-		msg = err.Error()
+		msg = "the token is invalid"
 		return nil, msg
 	}
 	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = fmt.Sprintf("token is expired")
-		msg = err.Error()
+		msg = "token is expired"
 		return nil, msg
 	}
 	return claims, msg
 }
-
-func UpdateAllTokens(signedToken string, signedRefreshToken string, userId string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-
-	var updateObj primitive.D
-	updateObj = append(updateObj, bson.E{"token", signedToken})
-	updateObj = append(updateObj, bson.E{"refresh_token", signedRefreshToken})
-	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, bson.E{"updated_at", Updated_at})
-	upsert := true
-
-	filter := bson.M{"user_id": userId}
-
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-	_, err := userCollection.UpdateOne(
-		ctx, filter, bson.D{{"$set", updateObj}}, &opt,
-	)
-	defer cancel()
-
-	if err != nil {
-		log.Panic(err)
-		return
-	}
-	return
-}
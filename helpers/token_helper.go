@@ -1,54 +1,90 @@
 package helpers
 
 import (
-	"github.com/o1egl/paseto"
-)
+	"context"
+	"time"
 
-var (
-	// Ganti dengan kunci rahasia Anda
-	secretKey = []byte("your_secret_key_here")
+	"github.com/o1egl/paseto/v2"
 )
 
-// Claims adalah struktur untuk menampung klaim token
+// Claims adalah struktur untuk menampung klaim token.
+// Semua format token (JWT, PASETO local, PASETO public) diverifikasi menjadi Claims yang sama.
 type Claims struct {
 	Email     string `json:"email"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Uid       string `json:"uid"`
+	Sid       string `json:"sid"`
 	UserType  string `json:"user_type"`
+	// Permissions berasal dari role user saat token diterbitkan
+	Permissions []string `json:"permissions"`
+	// Tid adalah organisasi aktif; OrgPermissions hanya berlaku di organisasi tersebut
+	Tid            string    `json:"tid"`
+	OrgPermissions []string  `json:"org_permissions"`
+	Jti            string    `json:"jti"`
+	IssuedAt       time.Time `json:"iat"`
+	ExpiresAt      time.Time `json:"exp"`
 }
 
-// NewV2Public adalah metode publik untuk membuat instance PASETO V2.
-func NewV2Public() *paseto.V2 {
-	return paseto.NewV2()
-}
-
-// GenerateToken menghasilkan token PASETO dari claim yang diberikan.
-func GenerateToken(claims Claims) (string, error) {
-	v2 := NewV2Public()
-
-	// Buat token PASETO
-	token, err := v2.Encrypt(secretKey, claims, nil)
+// GenerateToken menghasilkan token PASETO v2.local dari claim yang diberikan.
+func (s *Services) GenerateToken(claims Claims, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	key, err := s.PasetoLocalKeys.Active(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	// Kembalikan token dalam bentuk JSON Web Token (JWT)
-	return token, nil
+	jsonToken, err := newJSONToken(claims, ttl)
+	if err != nil {
+		return "", err
+	}
+	return paseto.NewV2().Encrypt(key.Secret, jsonToken, PasetoFooter{Kid: key.Kid})
 }
 
-// VerifyToken memverifikasi token PASETO dan mengembalikan claims jika valid.
-func VerifyTokenn(token string) (Claims, error) {
-	var claims Claims
-
-	// Buat dekoder PASETO
-	v2 := NewV2Public()
-
-	// Verifikasi token
-	err := v2.Decrypt(token, secretKey, &claims, nil)
+// newJSONToken menyusun payload PASETO dari claims
+func newJSONToken(claims Claims, ttl time.Duration) (paseto.JSONToken, error) {
+	jti, err := NewTokenID()
 	if err != nil {
+		return paseto.JSONToken{}, err
+	}
+	now := time.Now()
+	jsonToken := paseto.JSONToken{
+		Jti:        jti,
+		Subject:    claims.Uid,
+		IssuedAt:   now,
+		NotBefore:  now,
+		Expiration: now.Add(ttl),
+	}
+	jsonToken.Set("email", claims.Email)
+	jsonToken.Set("first_name", claims.FirstName)
+	jsonToken.Set("last_name", claims.LastName)
+	jsonToken.Set("user_type", claims.UserType)
+	jsonToken.Set("permissions", claims.Permissions)
+	jsonToken.Set("tid", claims.Tid)
+	jsonToken.Set("org_permissions", claims.OrgPermissions)
+	jsonToken.Set("sid", claims.Sid)
+	return jsonToken, nil
+}
+
+// claimsFromJSONToken memvalidasi waktu berlaku payload PASETO lalu mengubahnya kembali menjadi Claims
+func claimsFromJSONToken(jsonToken paseto.JSONToken) (Claims, error) {
+	if err := jsonToken.Validate(); err != nil {
 		return Claims{}, err
 	}
 
+	var claims Claims
+	jsonToken.Get("email", &claims.Email)
+	jsonToken.Get("first_name", &claims.FirstName)
+	jsonToken.Get("last_name", &claims.LastName)
+	jsonToken.Get("user_type", &claims.UserType)
+	jsonToken.Get("permissions", &claims.Permissions)
+	jsonToken.Get("tid", &claims.Tid)
+	jsonToken.Get("org_permissions", &claims.OrgPermissions)
+	jsonToken.Get("sid", &claims.Sid)
+	claims.Uid = jsonToken.Subject
+	claims.Jti = jsonToken.Jti
+	claims.IssuedAt = jsonToken.IssuedAt
+	claims.ExpiresAt = jsonToken.Expiration
 	return claims, nil
 }
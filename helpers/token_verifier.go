@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format token yang dikenali middleware.Authenticate
+const (
+	TokenFormatJWT          = "jwt"
+	TokenFormatPasetoLocal  = "paseto-local"
+	TokenFormatPasetoPublic = "paseto-public"
+)
+
+// DefaultTokenFormats dipakai jika AUTH_TOKEN_FORMATS tidak diisi
+const DefaultTokenFormats = TokenFormatJWT + "," + TokenFormatPasetoLocal + "," + TokenFormatPasetoPublic
+
+// TokenVerifier memverifikasi satu format token dan mengembalikan Claims yang seragam
+type TokenVerifier interface {
+	Verify(token string) (Claims, error)
+}
+
+// JWTVerifier memverifikasi access token JWT HS256 dari keystore Services.SigningKeys
+type JWTVerifier struct {
+	services *Services
+}
+
+func (v JWTVerifier) Verify(token string) (Claims, error) {
+	details, msg := v.services.ValidateToken(token)
+	if msg != "" {
+		return Claims{}, errors.New(msg)
+	}
+	if details.Refresh {
+		return Claims{}, errors.New("refresh token cannot be used to authenticate")
+	}
+	return Claims{
+		Email:          details.Email,
+		FirstName:      details.First_name,
+		LastName:       details.Last_name,
+		Uid:            details.Uid,
+		UserType:       details.User_type,
+		Permissions:    details.Permissions,
+		Tid:            details.Tid,
+		OrgPermissions: details.Org_permissions,
+		Sid:            details.Session_id,
+		Jti:            details.Id,
+		IssuedAt:       time.Unix(details.IssuedAt, 0),
+		ExpiresAt:      time.Unix(details.ExpiresAt, 0),
+	}, nil
+}
+
+// PasetoLocalVerifier memverifikasi token PASETO v2.local
+type PasetoLocalVerifier struct {
+	services *Services
+}
+
+func (v PasetoLocalVerifier) Verify(token string) (Claims, error) {
+	return v.services.VerifyToken(token)
+}
+
+// PasetoPublicVerifier memverifikasi token PASETO v2.public
+type PasetoPublicVerifier struct {
+	services *Services
+}
+
+func (v PasetoPublicVerifier) Verify(token string) (Claims, error) {
+	return v.services.VerifyPublicPasetoToken(token)
+}
+
+// DetectTokenFormat menentukan format token dari prefix-nya; string kosong jika tidak dikenali
+func DetectTokenFormat(token string) string {
+	switch {
+	case strings.HasPrefix(token, "v2.local."):
+		return TokenFormatPasetoLocal
+	case strings.HasPrefix(token, "v2.public."):
+		return TokenFormatPasetoPublic
+	case strings.Count(token, ".") == 2:
+		return TokenFormatJWT
+	}
+	return ""
+}
+
+// TokenVerifiers membuat daftar verifier dari daftar format yang dipisah koma (mis. "jwt,paseto-public").
+// Format yang tidak disebut tidak akan diterima oleh middleware.
+func (s *Services) TokenVerifiers(formats string) (map[string]TokenVerifier, error) {
+	if strings.TrimSpace(formats) == "" {
+		formats = DefaultTokenFormats
+	}
+	verifiers := map[string]TokenVerifier{}
+	for _, format := range strings.Split(formats, ",") {
+		switch format = strings.TrimSpace(format); format {
+		case TokenFormatJWT:
+			verifiers[format] = JWTVerifier{services: s}
+		case TokenFormatPasetoLocal:
+			verifiers[format] = PasetoLocalVerifier{services: s}
+		case TokenFormatPasetoPublic:
+			verifiers[format] = PasetoPublicVerifier{services: s}
+		default:
+			return nil, fmt.Errorf("unknown token format %q", format)
+		}
+	}
+	return verifiers, nil
+}
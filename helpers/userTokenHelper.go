@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golangsidang/models"
+	"golangsidang/repository"
+	"time"
+)
+
+// Umur token reset password dan token verifikasi email
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = time.Hour * time.Duration(24)
+)
+
+// ErrInvalidUserToken dikembalikan ketika token tidak dikenal, sudah dipakai atau kedaluwarsa
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// IssueUserToken membuat token sekali pakai untuk user; token lama dengan purpose yang sama ikut dibatalkan
+func (s *Services) IssueUserToken(ctx context.Context, userId string, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := s.storeUserToken(ctx, userId, purpose, hashUserToken(token), ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// storeUserToken menyimpan hash token baru setelah membatalkan token lama dengan purpose yang sama
+func (s *Services) storeUserToken(ctx context.Context, userId string, purpose string, tokenHash string, ttl time.Duration) error {
+	if err := s.UserTokens.DeleteByUser(ctx, userId, purpose); err != nil {
+		return err
+	}
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return s.UserTokens.Create(ctx, models.UserToken{
+		Token_hash: tokenHash,
+		Purpose:    purpose,
+		User_id:    userId,
+		Created_at: now,
+		Expires_at: now.Add(ttl),
+	})
+}
+
+// ConsumeUserToken memakai token sekali pakai dan mengembalikan id user pemiliknya
+func (s *Services) ConsumeUserToken(ctx context.Context, purpose string, token string) (string, error) {
+	userToken, err := s.TakeUserToken(ctx, purpose, token)
+	if err != nil {
+		return "", err
+	}
+	return userToken.User_id, nil
+}
+
+// TakeUserToken seperti ConsumeUserToken, tetapi mengembalikan token lengkap supaya bisa
+// disimpan kembali dengan RestoreUserToken jika permintaannya ternyata ditolak
+func (s *Services) TakeUserToken(ctx context.Context, purpose string, token string) (models.UserToken, error) {
+	userToken, err := s.UserTokens.Consume(ctx, purpose, hashUserToken(token), time.Now())
+	if errors.Is(err, repository.ErrUserTokenNotFound) {
+		return models.UserToken{}, ErrInvalidUserToken
+	}
+	return userToken, err
+}
+
+// RestoreUserToken menyimpan kembali token dari TakeUserToken sehingga masih bisa dipakai
+func (s *Services) RestoreUserToken(ctx context.Context, userToken models.UserToken) error {
+	return s.UserTokens.Create(ctx, userToken)
+}
+
+// UserTokenIssuedWithin mengecek apakah user sudah dikirimi token dengan purpose yang sama dalam interval terakhir,
+// dipakai untuk membatasi pengiriman ulang email
+func (s *Services) UserTokenIssuedWithin(ctx context.Context, userId string, purpose string, interval time.Duration) (bool, error) {
+	latest, err := s.UserTokens.FindLatest(ctx, userId, purpose)
+	if errors.Is(err, repository.ErrUserTokenNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return time.Since(latest.Created_at) < interval, nil
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
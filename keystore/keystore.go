@@ -0,0 +1,253 @@
+package keystore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"golangsidang/models"
+	"sync"
+	"time"
+)
+
+const (
+	// AlgorithmHS256 adalah algoritma untuk kunci simetris JWT
+	AlgorithmHS256 = "HS256"
+	// AlgorithmEd25519 adalah algoritma untuk pasangan kunci PASETO v2.public
+	AlgorithmEd25519 = "Ed25519"
+	// AlgorithmXChaCha20Poly1305 adalah algoritma untuk kunci simetris PASETO v2.local
+	AlgorithmXChaCha20Poly1305 = "XChaCha20-Poly1305"
+
+	secretSize   = 64
+	localKeySize = 32          // v2.local mewajibkan kunci 32 byte
+	reloadPeriod = time.Minute // cache kunci dimuat ulang secara berkala agar rotasi di instance lain ikut terbaca
+	// missReloadPeriod membatasi reload karena kid tidak dikenal, supaya token palsu dengan kid acak
+	// tidak memicu satu query database per request
+	missReloadPeriod = 5 * time.Second
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+	ErrKeyExpired  = errors.New("signing key has expired")
+	ErrUnsupported = errors.New("unsupported signing key algorithm")
+)
+
+// Store menyimpan kunci penandatangan berversi di Repository dan menyimpan salinannya di memori.
+// Store yang sama dipakai saat menerbitkan token maupun saat memverifikasinya.
+type Store struct {
+	repository Repository
+	algorithm  string
+	grace      time.Duration
+	seed       []byte
+
+	mu           sync.RWMutex
+	keys         []models.SigningKey // urut dari yang terbaru
+	loadedAt     time.Time
+	missReloadAt time.Time // reload terakhir karena kid tidak dikenal
+}
+
+// New membuat Store untuk satu algoritma. grace adalah lama kunci yang sudah di-retire masih diterima
+// untuk verifikasi, seed (boleh kosong, hanya untuk HS256) dipakai sebagai secret kunci pertama
+// jika repository masih kosong.
+func New(repository Repository, algorithm string, grace time.Duration, seed []byte) *Store {
+	return &Store{repository: repository, algorithm: algorithm, grace: grace, seed: seed}
+}
+
+// Active mengembalikan kunci yang dipakai untuk menandatangani token baru.
+// Jika belum ada kunci sama sekali, kunci pertama dibuat.
+func (s *Store) Active(ctx context.Context) (models.SigningKey, error) {
+	if err := s.ensureLoaded(ctx); err != nil {
+		return models.SigningKey{}, err
+	}
+	if key, ok := s.active(); ok {
+		return key, nil
+	}
+
+	if _, err := s.insert(ctx, s.seed); err != nil {
+		return models.SigningKey{}, err
+	}
+	if err := s.reload(ctx); err != nil {
+		return models.SigningKey{}, err
+	}
+	if key, ok := s.active(); ok {
+		return key, nil
+	}
+	return models.SigningKey{}, ErrKeyNotFound
+}
+
+// Lookup mencari kunci berdasarkan kid untuk verifikasi. Kunci yang sudah di-retire
+// lebih lama dari masa tenggang dianggap kedaluwarsa.
+func (s *Store) Lookup(ctx context.Context, kid string) (models.SigningKey, error) {
+	if err := s.ensureLoaded(ctx); err != nil {
+		return models.SigningKey{}, err
+	}
+	key, ok := s.find(kid)
+	if !ok {
+		// kunci bisa saja baru dibuat oleh instance lain, tetapi reload paling sering sekali per missReloadPeriod
+		if !s.claimMissReload() {
+			return models.SigningKey{}, ErrKeyNotFound
+		}
+		if err := s.reload(ctx); err != nil {
+			return models.SigningKey{}, err
+		}
+		if key, ok = s.find(kid); !ok {
+			return models.SigningKey{}, ErrKeyNotFound
+		}
+	}
+	if key.Retired_at != nil && time.Now().After(key.Retired_at.Add(s.grace)) {
+		return models.SigningKey{}, ErrKeyExpired
+	}
+	return key, nil
+}
+
+// Rotate membuat kunci aktif baru dan me-retire kunci yang sebelumnya aktif.
+// Token yang ditandatangani kunci lama tetap valid selama masa tenggang.
+func (s *Store) Rotate(ctx context.Context) (models.SigningKey, error) {
+	key, err := s.insert(ctx, nil)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	if err := s.repository.RetireAllExcept(ctx, key.Kid, key.Created_at); err != nil {
+		return models.SigningKey{}, err
+	}
+	if err := s.reload(ctx); err != nil {
+		return models.SigningKey{}, err
+	}
+	return key, nil
+}
+
+// Keys mengembalikan semua kunci yang masih bisa dipakai untuk verifikasi (tanpa secret pada JSON).
+func (s *Store) Keys(ctx context.Context) ([]models.SigningKey, error) {
+	if err := s.reload(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []models.SigningKey
+	for _, key := range s.keys {
+		if key.Retired_at == nil || time.Now().Before(key.Retired_at.Add(s.grace)) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Algorithm mengembalikan algoritma kunci yang dikelola Store
+func (s *Store) Algorithm() string {
+	return s.algorithm
+}
+
+func (s *Store) insert(ctx context.Context, seed []byte) (models.SigningKey, error) {
+	kid, err := newKid()
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	created_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	key := models.SigningKey{
+		Kid:        kid,
+		Algorithm:  s.algorithm,
+		Created_at: created_at,
+	}
+	switch s.algorithm {
+	case AlgorithmHS256:
+		key.Secret = seed
+		if len(key.Secret) == 0 {
+			if key.Secret, err = newSecret(); err != nil {
+				return models.SigningKey{}, err
+			}
+		}
+	case AlgorithmXChaCha20Poly1305:
+		key.Secret = make([]byte, localKeySize)
+		if _, err := rand.Read(key.Secret); err != nil {
+			return models.SigningKey{}, err
+		}
+	case AlgorithmEd25519:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return models.SigningKey{}, err
+		}
+		key.Secret = privateKey
+		key.Public_key = publicKey
+	default:
+		return models.SigningKey{}, ErrUnsupported
+	}
+	if err := s.repository.Insert(ctx, key); err != nil {
+		return models.SigningKey{}, err
+	}
+	return key, nil
+}
+
+func (s *Store) ensureLoaded(ctx context.Context) error {
+	s.mu.RLock()
+	fresh := !s.loadedAt.IsZero() && time.Since(s.loadedAt) < reloadPeriod
+	s.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+	return s.reload(ctx)
+}
+
+// claimMissReload mengizinkan satu reload karena kid tidak dikenal per missReloadPeriod, juga saat banyak request bersamaan
+func (s *Store) claimMissReload() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.missReloadAt) < missReloadPeriod || time.Since(s.loadedAt) < missReloadPeriod {
+		return false
+	}
+	s.missReloadAt = time.Now()
+	return true
+}
+
+func (s *Store) reload(ctx context.Context) error {
+	keys, err := s.repository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Store) active() (models.SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.Retired_at == nil {
+			return key, true
+		}
+	}
+	return models.SigningKey{}, false
+}
+
+func (s *Store) find(kid string) (models.SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+	return models.SigningKey{}, false
+}
+
+func newSecret() ([]byte, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func newKid() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"golangsidang/models"
+	"testing"
+	"time"
+)
+
+func TestActive(t *testing.T) {
+	ctx := context.Background()
+	repository := NewMemoryRepository()
+	seed := []byte("secret-from-config")
+	store := New(repository, AlgorithmHS256, time.Hour, seed)
+
+	key, err := store.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Secret, seed) {
+		t.Error("first HS256 key does not use the seed")
+	}
+	again, err := store.Active(ctx)
+	if err != nil || again.Kid != key.Kid {
+		t.Fatalf("second Active = %s, %v; want %s", again.Kid, err, key.Kid)
+	}
+
+	// instance lain di atas repository yang sama memakai kunci yang sama
+	other, err := New(repository, AlgorithmHS256, time.Hour, nil).Active(ctx)
+	if err != nil || other.Kid != key.Kid {
+		t.Errorf("other store Active = %s, %v; want %s", other.Kid, err, key.Kid)
+	}
+
+	public, err := New(NewMemoryRepository(), AlgorithmEd25519, time.Hour, nil).Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public.Public_key) != ed25519.PublicKeySize || len(public.Secret) != ed25519.PrivateKeySize {
+		t.Errorf("Ed25519 key sizes = %d/%d", len(public.Public_key), len(public.Secret))
+	}
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	store := New(NewMemoryRepository(), AlgorithmHS256, time.Hour, nil)
+	old, err := store.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := store.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := store.Active(ctx); active.Kid != rotated.Kid || rotated.Kid == old.Kid {
+		t.Fatalf("active kid = %s, want the rotated kid %s", active.Kid, rotated.Kid)
+	}
+
+	// kunci lama masih diterima selama masa tenggang
+	retired, err := store.Lookup(ctx, old.Kid)
+	if err != nil {
+		t.Fatalf("Lookup of the retired key: %v", err)
+	}
+	if retired.Retired_at == nil {
+		t.Error("previous key was not retired")
+	}
+	if keys, _ := store.Keys(ctx); len(keys) != 2 {
+		t.Errorf("Keys returned %d keys, want 2", len(keys))
+	}
+	if _, err := store.Lookup(ctx, "unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Lookup of an unknown kid = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	repository := NewMemoryRepository()
+	retiredAt := time.Now().Add(-2 * time.Hour)
+	expired := models.SigningKey{Kid: "expired", Algorithm: AlgorithmHS256, Secret: []byte("old"), Created_at: retiredAt.Add(-time.Hour), Retired_at: &retiredAt}
+	if err := repository.Insert(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	store := New(repository, AlgorithmHS256, time.Hour, nil)
+	if _, err := store.Lookup(ctx, "expired"); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("Lookup after the grace period = %v, want ErrKeyExpired", err)
+	}
+	if keys, _ := store.Keys(ctx); len(keys) != 0 {
+		t.Errorf("Keys returned %d keys, want none", len(keys))
+	}
+
+	// masa tenggang yang lebih panjang masih menerima kunci yang sama
+	if _, err := New(repository, AlgorithmHS256, 3*time.Hour, nil).Lookup(ctx, "expired"); err != nil {
+		t.Errorf("Lookup within a longer grace period: %v", err)
+	}
+}
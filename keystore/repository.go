@@ -0,0 +1,97 @@
+package keystore
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository adalah tempat penyimpanan permanen kunci milik Store
+type Repository interface {
+	Insert(ctx context.Context, key models.SigningKey) error
+	// FindAll mengembalikan semua kunci, yang terbaru lebih dulu
+	FindAll(ctx context.Context) ([]models.SigningKey, error)
+	// RetireAllExcept me-retire semua kunci aktif selain kid pada waktu at
+	RetireAllExcept(ctx context.Context, kid string, at time.Time) error
+}
+
+// MongoRepository menyimpan kunci di collection MongoDB
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRepository(collection *mongo.Collection) *MongoRepository {
+	return &MongoRepository{collection: collection}
+}
+
+func (r *MongoRepository) Insert(ctx context.Context, key models.SigningKey) error {
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *MongoRepository) FindAll(ctx context.Context) ([]models.SigningKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var keys []models.SigningKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *MongoRepository) RetireAllExcept(ctx context.Context, kid string, at time.Time) error {
+	filter := bson.M{"retired_at": bson.M{"$exists": false}, "kid": bson.M{"$ne": kid}}
+	update := bson.M{"$set": bson.M{"retired_at": at}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// MemoryRepository menyimpan kunci di memori; kunci hilang saat proses berhenti,
+// jadi hanya cocok untuk development dan pengujian
+type MemoryRepository struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (r *MemoryRepository) Insert(ctx context.Context, key models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *MemoryRepository) FindAll(ctx context.Context) ([]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]models.SigningKey, 0, len(r.keys))
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		keys = append(keys, r.keys[i])
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Created_at.After(keys[j].Created_at) })
+	return keys, nil
+}
+
+func (r *MemoryRepository) RetireAllExcept(ctx context.Context, kid string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if r.keys[i].Kid != kid && r.keys[i].Retired_at == nil {
+			retiredAt := at
+			r.keys[i].Retired_at = &retiredAt
+		}
+	}
+	return nil
+}
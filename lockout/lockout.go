@@ -0,0 +1,125 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Attempt adalah catatan login gagal untuk satu key (email atau IP)
+type Attempt struct {
+	Key          string    `bson:"_id"`
+	Failures     int       `bson:"failures"`
+	Locked_until time.Time `bson:"locked_until"`
+	Expires_at   time.Time `bson:"expires_at"` // catatan dibuang setelah waktu ini, sehingga hitungan gagal mulai dari nol
+}
+
+// Store menyimpan hitungan login gagal. Ada implementasi MongoDB (dipakai bersama semua instance) dan memori.
+type Store interface {
+	// Get mengembalikan catatan yang belum kedaluwarsa pada waktu now; Attempt kosong jika tidak ada
+	Get(ctx context.Context, key string, now time.Time) (Attempt, error)
+	// Increment menambah satu kegagalan dan memperpanjang catatan sampai now+window, lalu mengembalikan jumlahnya
+	Increment(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	// Lock mengunci key sampai until
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Decrement mengurangi satu kegagalan pada catatan yang belum kedaluwarsa, tidak pernah di bawah nol dan tanpa membuka kunci
+	Decrement(ctx context.Context, key string, now time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy mengatur kapan key dikunci. Setelah MaxFailures kegagalan, key dikunci selama BaseDelay,
+// dan lamanya berlipat dua untuk setiap kegagalan berikutnya sampai MaxDelay.
+type Policy struct {
+	MaxFailures int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Window      time.Duration // hitungan gagal dilupakan setelah selama ini tanpa kegagalan baru
+}
+
+// Policy bawaan: email dikunci lebih cepat, IP lebih longgar karena bisa dipakai bersama (NAT)
+var (
+	DefaultEmailPolicy = Policy{MaxFailures: 5, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 15 * time.Minute}
+	DefaultIPPolicy    = Policy{MaxFailures: 20, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: 15 * time.Minute}
+)
+
+// LockDuration mengembalikan lama kunci setelah failures kegagalan; 0 jika belum perlu dikunci
+func (p Policy) LockDuration(failures int) time.Duration {
+	if failures < p.MaxFailures {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.MaxFailures; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Guard menerapkan Policy per email dan per IP di atas Store
+type Guard struct {
+	store       Store
+	emailPolicy Policy
+	ipPolicy    Policy
+}
+
+func NewGuard(store Store, emailPolicy Policy, ipPolicy Policy) *Guard {
+	return &Guard{store: store, emailPolicy: emailPolicy, ipPolicy: ipPolicy}
+}
+
+// Check mengembalikan sisa waktu kunci untuk email atau IP ini; 0 berarti login boleh dicoba
+func (g *Guard) Check(ctx context.Context, email string, ip string) (time.Duration, error) {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range []string{emailKey(email), ipKey(ip)} {
+		attempt, err := g.store.Get(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		if remaining := attempt.Locked_until.Sub(now); remaining > retryAfter {
+			retryAfter = remaining
+		}
+	}
+	return retryAfter, nil
+}
+
+// Fail mencatat login gagal untuk email dan IP, mengunci key yang melewati batas
+func (g *Guard) Fail(ctx context.Context, email string, ip string) error {
+	now := time.Now()
+	for key, policy := range map[string]Policy{emailKey(email): g.emailPolicy, ipKey(ip): g.ipPolicy} {
+		failures, err := g.store.Increment(ctx, key, now, policy.Window)
+		if err != nil {
+			return err
+		}
+		if delay := policy.LockDuration(failures); delay > 0 {
+			if err := g.store.Lock(ctx, key, now.Add(delay)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Succeed menghapus hitungan gagal email setelah login berhasil dan mengurangi satu kegagalan IP.
+// Hitungan IP tidak dihapus, supaya penyerang yang punya satu akun tidak bisa mereset batas IP-nya,
+// tetapi typo user lain di balik NAT yang sama perlahan dilupakan.
+func (g *Guard) Succeed(ctx context.Context, email string, ip string) error {
+	if err := g.store.Reset(ctx, emailKey(email)); err != nil {
+		return err
+	}
+	return g.store.Decrement(ctx, ipKey(ip), time.Now())
+}
+
+// Unlock membuka kunci email secara manual (admin)
+func (g *Guard) Unlock(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, emailKey(email))
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockDuration(t *testing.T) {
+	policy := Policy{MaxFailures: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Window: time.Hour}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{6, 8 * time.Minute},
+		{7, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.LockDuration(tt.failures); got != tt.want {
+			t.Errorf("LockDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	policy := Policy{MaxFailures: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+	guard := NewGuard(NewMemoryStore(), policy, Policy{MaxFailures: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour})
+
+	if err := guard.Fail(ctx, "Bob@Example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter, _ := guard.Check(ctx, "bob@example.com", "10.0.0.2"); retryAfter != 0 {
+		t.Fatalf("locked after one failure: %v", retryAfter)
+	}
+
+	// kegagalan kedua mengunci email, juga dari IP lain
+	if err := guard.Fail(ctx, "bob@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	retryAfter, err := guard.Check(ctx, "bob@example.com", "10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("retry after = %v, want up to %v", retryAfter, time.Minute)
+	}
+
+	// kegagalan berikutnya melipatgandakan lama kunci
+	if err := guard.Fail(ctx, "bob@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter, _ = guard.Check(ctx, "bob@example.com", "10.0.0.2"); retryAfter <= time.Minute {
+		t.Fatalf("retry after = %v, want more than %v", retryAfter, time.Minute)
+	}
+
+	if err := guard.Unlock(ctx, "bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if retryAfter, _ = guard.Check(ctx, "bob@example.com", "10.0.0.2"); retryAfter != 0 {
+		t.Fatalf("still locked after Unlock: %v", retryAfter)
+	}
+}
@@ -0,0 +1,81 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore adalah Store di memori, cocok untuk satu instance atau untuk development
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: map[string]Attempt{}}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string, now time.Time) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, ok := s.attempts[key]
+	if !ok || !now.Before(attempt.Expires_at) {
+		return Attempt{}, nil
+	}
+	return attempt, nil
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(now)
+	attempt := s.attempts[key]
+	attempt.Key = key
+	attempt.Failures++
+	if expiresAt := now.Add(window); expiresAt.After(attempt.Expires_at) {
+		attempt.Expires_at = expiresAt
+	}
+	s.attempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt := s.attempts[key]
+	attempt.Key = key
+	attempt.Locked_until = until
+	if until.After(attempt.Expires_at) {
+		attempt.Expires_at = until
+	}
+	s.attempts[key] = attempt
+	return nil
+}
+
+func (s *MemoryStore) Decrement(ctx context.Context, key string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, ok := s.attempts[key]
+	if ok && now.Before(attempt.Expires_at) && attempt.Failures > 0 {
+		attempt.Failures--
+		s.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// prune membuang catatan yang sudah kedaluwarsa, dipanggil dengan lock dipegang
+func (s *MemoryStore) prune(now time.Time) {
+	for key, attempt := range s.attempts {
+		if !now.Before(attempt.Expires_at) {
+			delete(s.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore adalah Store di MongoDB. Dokumen dihapus otomatis oleh TTL index pada expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (s *MongoStore) Get(ctx context.Context, key string, now time.Time) (Attempt, error) {
+	var attempt Attempt
+	err := s.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return Attempt{}, nil
+	}
+	return attempt, err
+}
+
+func (s *MongoStore) Increment(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	if err := s.ensureIndexes(ctx); err != nil {
+		return 0, err
+	}
+	// TTL monitor MongoDB berjalan per menit, jadi catatan yang sudah kedaluwarsa dibuang dulu di sini
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$lte": now}}); err != nil {
+		return 0, err
+	}
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$max": bson.M{"expires_at": now.Add(window)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var attempt Attempt
+	if err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempt); err != nil {
+		return 0, err
+	}
+	return attempt.Failures, nil
+}
+
+func (s *MongoStore) Lock(ctx context.Context, key string, until time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"locked_until": until},
+		"$max": bson.M{"expires_at": until},
+	}
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": key}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoStore) Decrement(ctx context.Context, key string, now time.Time) error {
+	filter := bson.M{"_id": key, "failures": bson.M{"$gt": 0}, "expires_at": bson.M{"$gt": now}}
+	_, err := s.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"failures": -1}})
+	return err
+}
+
+func (s *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// ensureIndexes membuat TTL index pada expires_at saat kegagalan pertama dicatat
+func (s *MongoStore) ensureIndexes(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexed {
+		return nil
+	}
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+	s.indexed = true
+	return nil
+}
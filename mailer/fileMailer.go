@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer menulis setiap email sebagai file .eml di Dir, untuk development tanpa server SMTP
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{Dir: dir, From: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitize(message.To))
+	// email berisi token rahasia, jadi hanya pemilik proses yang boleh membacanya
+	return os.WriteFile(filepath.Join(m.Dir, name), format(m.From, message), 0o600)
+}
+
+// sanitize membuang karakter yang tidak aman dipakai di nama file
+func sanitize(address string) string {
+	out := []rune{}
+	for _, r := range address {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '.', r == '-', r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
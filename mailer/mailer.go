@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Driver mailer yang didukung
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+// Message adalah email teks biasa
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. SMTPMailer untuk produksi, FileMailer dan MemoryMailer untuk development dan pengujian.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// format menyusun pesan RFC 5322 sederhana, dipakai SMTPMailer dan FileMailer
+func format(from string, message Message) []byte {
+	return []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, message.To, message.Subject, message.Body))
+}
@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer menyimpan email yang dikirim di memori, untuk pengujian
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages mengembalikan salinan semua email yang sudah dikirim, yang terlama lebih dulu
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -1,33 +1,48 @@
 package main
 
 import (
-	routes "golangsidang/routes"
-	// import routes
+	"context"
+	"golangsidang/app"
+	"golangsidang/config"
+	"golangsidang/database"
+	"golangsidang/migrations"
+	"log"
 	"os"
-
-	"github.com/gin-gonic/gin" // import gin
 )
 
 func main() {
-	port := os.Getenv("PORT") // mengambil port dari env
-
-	if port == "" {
-		port = "8080" //localhost
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if err := run(logger); err != nil {
+		logger.Fatal(err)
 	}
-	router := gin.New()      // membuat router baru
-	router.Use(gin.Logger()) // menggunakan logger
+}
 
-	routes.AuthRoutes(router) // menggunakan routes auth
-	routes.UserRoutes(router) // menggunakan routes user
+func run(logger *log.Logger) error {
+	cfg, err := config.Load(os.Args[1:]) // env, .env, file YAML/JSON dan flag
+	if err != nil {
+		return err
+	}
 
-	router.GET("/api-1", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Hello World",
-		}) // membuat api di passing menjadi berupa json dan memunculkan hello world
-	})
-	router.GET("/api-2", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "Hello World"}) // membuat api di passing menjadi berupa json dan memunculkan hello world
-	})
+	// storage memory menjalankan seluruh API tanpa MongoDB
+	var deps app.Dependencies
+	if cfg.Storage == config.StorageMemory {
+		deps = app.MemoryDependencies(cfg, logger)
+	} else {
+		client, err := database.DBinstance(context.Background(), cfg.MongoURL)
+		if err != nil {
+			return err
+		}
+		defer client.Disconnect(context.Background())
+		logger.Println("Connected to MongoDB!")
+		if err := migrations.Run(context.Background(), database.OpenDatabase(client), migrations.All, logger); err != nil {
+			return err
+		}
+		deps = app.MongoDependencies(client, cfg, logger)
+	}
 
-	router.Run(":" + port) // menjalankan router di port yang diambil dari env (localhost
+	application, err := app.New(cfg, deps)
+	if err != nil {
+		return err
+	}
+	return application.Run() // menjalankan router di port dari konfigurasi
 }
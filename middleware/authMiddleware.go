@@ -1,33 +1,140 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	helper "golangsidang/helpers"
+	"golangsidang/repository"
+	"golangsidang/revocation"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Authenticate() gin.HandlerFunc {
+// Authenticate memilih verifier berdasarkan prefix token (v2.local., v2.public., atau JWT)
+// dan mengisi context gin dengan key yang sama untuk semua format.
+// verifiers dibuat dari format yang diaktifkan per deployment, lihat helpers.Services.TokenVerifiers.
+// Header "Authorization: ApiKey <key>" diterima juga, lihat authenticateAPIKey.
+func Authenticate(verifiers map[string]helper.TokenVerifier, users repository.UserRepository, services *helper.Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientToken := c.Request.Header.Get("Authorization")
+		header := c.Request.Header.Get("Authorization")
+		if apiKey := strings.TrimPrefix(header, "ApiKey "); apiKey != header {
+			authenticateAPIKey(c, users, services, apiKey)
+			return
+		}
+		clientToken := strings.TrimPrefix(header, "Bearer ")
 		if clientToken == "" {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("token not found")})
 			c.Abort() // jika token tidak ditemukan maka akan mengembalikan error
 			return
 		}
-		claims, err := helper.ValidateToken(clientToken)
-		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+		verifier, ok := verifiers[helper.DetectTokenFormat(clientToken)]
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "unsupported token format"})
+			c.Abort()
+			return
+		}
+		claims, err := verifier.Verify(clientToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 			c.Abort()
 			return
 
 		}
+		revoked, err := revocation.IsTokenRevoked(c.Request.Context(), services.Revocations, claims.Jti, claims.Uid, claims.IssuedAt)
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to check token revocation"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "token has been revoked"})
+			c.Abort()
+			return
+		}
+		if claims.Sid == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "token is not bound to a session"})
+			c.Abort()
+			return
+		}
+		session, err := services.FindSession(c.Request.Context(), claims.Sid)
+		if errors.Is(err, helper.ErrSessionNotFound) || (err == nil && session.User_id != claims.Uid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "session has ended"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("Error loading session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to load session"})
+			c.Abort()
+			return
+		}
+		if err := services.TouchSession(c.Request.Context(), session); err != nil {
+			log.Printf("Error updating session last seen: %v", err)
+		}
 		c.Set("email", claims.Email)
-		c.Set("first_name", claims.First_name)
-		c.Set("last_name", claims.Last_name)
+		c.Set("first_name", claims.FirstName)
+		c.Set("last_name", claims.LastName)
 		c.Set("uid", claims.Uid)
-		c.Set("user_type", claims.User_type)
+		c.Set("user_type", claims.UserType)
+		c.Set("permissions", claims.Permissions)
+		c.Set("tenant_id", claims.Tid)
+		c.Set("org_permissions", claims.OrgPermissions)
+		c.Set("session_id", claims.Sid)
+		c.Set("jti", claims.Jti)
+		c.Set("token_expires_at", claims.ExpiresAt)
 		c.Next()
 	}
 }
+
+// authenticateAPIKey mengisi context gin dengan key yang sama seperti token sesi. Data user dan permission-nya
+// dibaca ulang setiap request, karena API key tidak membawa claim; session_id dan jti dibiarkan kosong.
+func authenticateAPIKey(c *gin.Context, users repository.UserRepository, services *helper.Services, apiKey string) {
+	ctx := c.Request.Context()
+	key, err := services.VerifyAPIKey(ctx, apiKey)
+	if errors.Is(err, helper.ErrInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to verify API key"})
+		c.Abort()
+		return
+	}
+	// akun yang dihapus tidak bisa dipakai lagi lewat API key-nya
+	user, err := users.FindByID(ctx, key.User_id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": helper.ErrInvalidAPIKey.Error()})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading API key owner: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to verify API key"})
+		c.Abort()
+		return
+	}
+	permissions, err := services.APIKeyPermissions(ctx, key, *user.User_type)
+	if err != nil {
+		log.Printf("Error loading API key permissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to verify API key"})
+		c.Abort()
+		return
+	}
+	if err := services.TouchAPIKey(ctx, key); err != nil {
+		log.Printf("Error updating API key last used: %v", err)
+	}
+	c.Set("email", *user.Email)
+	c.Set("first_name", *user.First_name)
+	c.Set("last_name", *user.Last_name)
+	c.Set("uid", *user.User_id)
+	c.Set("user_type", *user.User_type)
+	c.Set("permissions", permissions)
+	c.Set("api_key_id", key.Key_id)
+	c.Next()
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	helper "golangsidang/helpers"
+	"golangsidang/ratelimit"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc menentukan siapa yang dibatasi untuk satu request
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP membatasi per alamat IP client. ClientIP hanya membaca X-Forwarded-For dari proxy
+// yang terdaftar di config TrustedProxies, selain itu alamat koneksi yang dipakai.
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser membatasi per uid dari middleware Authenticate, atau per IP jika belum login
+func KeyByUser(c *gin.Context) string {
+	if uid := c.GetString("uid"); uid != "" {
+		return "uid:" + uid
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey membatasi per API key untuk request yang diautentikasi dengan API key, selain itu seperti KeyByUser.
+// Setiap client mesin mendapat kuota sendiri yang tidak menghabiskan kuota sesi pemiliknya.
+// Harus dipasang setelah middleware Authenticate.
+func KeyByAPIKey(c *gin.Context) string {
+	if keyId := c.GetString("api_key_id"); keyId != "" {
+		return "apikey:" + keyId
+	}
+	return KeyByUser(c)
+}
+
+// RateLimiter membuat middleware rate limit per route dari policy yang sudah dikonfigurasi
+type RateLimiter struct {
+	store    ratelimit.Store
+	policies map[string]ratelimit.Policy
+}
+
+func NewRateLimiter(store ratelimit.Store, policies map[string]ratelimit.Policy) *RateLimiter {
+	return &RateLimiter{store: store, policies: policies}
+}
+
+// Limit membatasi route dengan policy bernama name, dihitung terpisah untuk setiap key.
+// Header RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset dan RateLimit-Policy selalu dikirim;
+// request yang ditolak mendapat 429 dengan Retry-After.
+func (l *RateLimiter) Limit(name string, key KeyFunc) gin.HandlerFunc {
+	policy, ok := l.policies[name]
+	if !ok || policy.Limit == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		result, err := l.store.Take(c.Request.Context(), name+":"+key(c), policy, time.Now())
+		if err != nil {
+			// rate limit tidak boleh membuat service mati ketika penyimpanannya bermasalah
+			log.Printf("Error checking rate limit %s: %v", name, err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", helper.RetryAfterSeconds(result.Reset))
+		c.Header("RateLimit-Policy", policy.String())
+		if !result.Allowed {
+			c.Header("Retry-After", helper.RetryAfterSeconds(result.RetryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"message": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	helper "golangsidang/helpers"
+	"golangsidang/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Require menolak request yang token-nya tidak memiliki permission tersebut.
+// Harus dipasang setelah Authenticate.
+func Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !helper.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "missing permission " + permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOr mengizinkan pemilik akun (uid sama dengan parameter route param)
+// atau user lain yang memiliki permission tersebut. Dengan API key, pemilik akun hanya boleh membaca (GET);
+// mengubah akunnya sendiri lewat API key tetap butuh scope permission tersebut.
+func RequireSelfOr(param string, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		self := helper.IsSelf(c, c.Param(param)) && (!helper.IsAPIKey(c) || c.Request.Method == http.MethodGet)
+		if !self && !helper.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "missing permission " + permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession menolak request yang memakai API key. Dipasang di route untuk mengelola akun sendiri
+// (password, sesi, MFA, API key, pindah organisasi) yang hanya boleh dilakukan dari sesi login.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if helper.IsAPIKey(c) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "this endpoint requires a login session, not an API key"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Tenant mewajibkan token yang berada di sebuah organisasi (claim tid) dan membatasi
+// query UserRepository dari request ini ke anggota organisasi tersebut (lihat repository.WithTenant).
+// Handler setelahnya harus memakai c.Request.Context() sebagai induk context query.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgId := c.GetString("tenant_id")
+		if orgId == "" {
+			c.JSON(http.StatusForbidden, gin.H{"message": "token is not scoped to an organization"})
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), orgId))
+		c.Next()
+	}
+}
+
+// RequireOrg menolak request yang tidak memiliki permission tersebut di organisasi aktif.
+// Harus dipasang setelah Tenant.
+func RequireOrg(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !helper.HasOrgPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "missing organization permission " + permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,270 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golangsidang/models"
+	"golangsidang/phone"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionName adalah collection tempat versi migrasi yang sudah dijalankan dicatat
+const CollectionName = "schema_migrations"
+
+// Migration adalah satu perubahan skema. Up harus aman dijalankan ulang (idempotent),
+// karena beberapa instance bisa start bersamaan.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	Applied_at  time.Time `bson:"applied_at"`
+}
+
+// Run menjalankan migrasi yang belum tercatat, urut dari versi terkecil
+func Run(ctx context.Context, db *mongo.Database, migrations []Migration, logger *log.Logger) error {
+	collection := db.Collection(CollectionName)
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("migrations: load applied versions: %w", err)
+	}
+	var applied []appliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return fmt.Errorf("migrations: load applied versions: %w", err)
+	}
+	done := map[int]bool{}
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	pending := append([]Migration(nil), migrations...)
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+	for _, migration := range pending {
+		if done[migration.Version] {
+			continue
+		}
+		logger.Printf("migrations: applying %d (%s)", migration.Version, migration.Description)
+		if err := migration.Up(ctx, db); err != nil {
+			return fmt.Errorf("migrations: %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		record := appliedMigration{Version: migration.Version, Description: migration.Description, Applied_at: time.Now().UTC()}
+		_, err := collection.ReplaceOne(ctx, bson.M{"_id": migration.Version}, record, options.Replace().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("migrations: record %d: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+// All adalah daftar migrasi service, versi baru ditambahkan di akhir
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "unique indexes on user email, phone and user_id",
+		Up:          userUniqueIndexes,
+	},
+	{
+		Version:     2,
+		Description: "index on user deleted_at for the purger",
+		Up:          userDeletedAtIndex,
+	},
+	{
+		Version:     3,
+		Description: "normalise user phone numbers to E.164",
+		Up:          normalizeUserPhones,
+	},
+	{
+		Version:     4,
+		Description: "seed built-in ADMIN and USER roles",
+		Up:          seedDefaultRoles,
+	},
+	{
+		Version:     5,
+		Description: "seed organization roles and index user memberships",
+		Up:          organizationMemberships,
+	},
+	{
+		Version:     6,
+		Description: "index invitation tokens and grant users:invite to ADMIN",
+		Up:          invitations,
+	},
+	{
+		Version:     7,
+		Description: "index API key prefixes and owners",
+		Up:          apiKeyIndexes,
+	},
+	{
+		Version:     8,
+		Description: "remove session tokens stored on user documents",
+		Up:          unsetUserTokens,
+	},
+	{
+		Version:     9,
+		Description: "add version to users for optimistic concurrency",
+		Up:          addUserVersion,
+	},
+	{
+		Version:     10,
+		Description: "store session refresh tokens as SHA-256 hashes",
+		Up:          hashSessionRefreshTokens,
+	},
+}
+
+func userUniqueIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "phone", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+func userDeletedAtIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	return err
+}
+
+// normalizeUserPhones mengubah phone lama ke format E.164. Nomor yang tidak bisa dinormalisasi dibiarkan;
+// jika dua user ternyata memakai nomor yang sama, migrasi gagal dan harus dibereskan manual.
+func normalizeUserPhones(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("user")
+	cursor, err := users.Find(ctx, bson.M{"phone": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var user struct {
+			ID    interface{} `bson:"_id"`
+			Phone string      `bson:"phone"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		normalized, err := phone.Normalize(user.Phone, phone.DefaultRegion)
+		if errors.Is(err, phone.ErrInvalidPhone) || normalized == user.Phone {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = users.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"phone": normalized}})
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("phone %s is used by more than one user: %w", normalized, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// seedDefaultRoles membuat role bawaan; role yang sudah ada (mis. sudah diubah admin) tidak ditimpa
+func seedDefaultRoles(ctx context.Context, db *mongo.Database) error {
+	roles := db.Collection("roles")
+	for _, role := range models.DefaultRoles() {
+		insert := bson.M{
+			"description": role.Description,
+			"permissions": role.Permissions,
+			"created_at":  role.Created_at,
+			"updated_at":  role.Updated_at,
+		}
+		_, err := roles.UpdateOne(ctx, bson.M{"_id": role.Name}, bson.M{"$setOnInsert": insert}, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// organizationMemberships menambahkan role ORG_ADMIN/ORG_MEMBER dan index untuk query yang dibatasi per organisasi
+func organizationMemberships(ctx context.Context, db *mongo.Database) error {
+	if err := seedDefaultRoles(ctx, db); err != nil {
+		return err
+	}
+	_, err := db.Collection("user").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "memberships.org_id", Value: 1}},
+	})
+	return err
+}
+
+// invitations membuat index token undangan. ADMIN yang sudah ada di database tidak ikut berubah oleh
+// seedDefaultRoles, jadi permission users:invite ditambahkan langsung.
+func invitations(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("invitations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$addToSet": bson.M{"permissions": models.PermissionUsersInvite}}
+	_, err = db.Collection("roles").UpdateOne(ctx, bson.M{"_id": models.RoleAdmin}, update)
+	return err
+}
+
+func apiKeyIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("api_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "prefix", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// unsetUserTokens menghapus token lama yang dulu disimpan di dokumen user; token sekarang hanya milik sesi
+func unsetUserTokens(ctx context.Context, db *mongo.Database) error {
+	unset := bson.M{"token": "", "refresh_token": "", "paseto_token": "", "public_paseto_token": ""}
+	_, err := db.Collection("user").UpdateMany(ctx, bson.M{}, bson.M{"$unset": unset})
+	return err
+}
+
+// addUserVersion memberi version 0 pada user lama, karena Update hanya cocok dengan version yang sama
+func addUserVersion(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user").UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": int64(0)}})
+	return err
+}
+
+// hashSessionRefreshTokens mengganti refresh token sesi yang masih tersimpan apa adanya dengan hash SHA-256-nya,
+// sama seperti helpers.InsertSession, sehingga sesi lama tetap bisa di-refresh
+func hashSessionRefreshTokens(ctx context.Context, db *mongo.Database) error {
+	sessions := db.Collection("session")
+	cursor, err := sessions.Find(ctx, bson.M{"refresh_token": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var session struct {
+			ID            interface{} `bson:"_id"`
+			Refresh_token string      `bson:"refresh_token"`
+		}
+		if err := cursor.Decode(&session); err != nil {
+			return err
+		}
+		sum := sha256.Sum256([]byte(session.Refresh_token))
+		update := bson.M{
+			"$set":   bson.M{"refresh_token_hash": hex.EncodeToString(sum[:])},
+			"$unset": bson.M{"refresh_token": ""},
+		}
+		if _, err := sessions.UpdateOne(ctx, bson.M{"_id": session.ID}, update); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
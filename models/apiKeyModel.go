@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// APIKey adalah kunci pribadi untuk klien mesin, dikirim sebagai "Authorization: ApiKey <key>".
+// Key lengkap hanya ditampilkan sekali saat dibuat; yang disimpan hanya Prefix untuk pencarian dan hash SHA-256-nya.
+type APIKey struct {
+	Key_id       string     `bson:"_id" json:"key_id"`
+	User_id      string     `json:"user_id"`
+	Name         string     `json:"name" validate:"required,min=1,max=100"`
+	Prefix       string     `json:"prefix"`
+	Key_hash     string     `json:"-"`
+	Scopes       []string   `json:"scopes"` // permission yang boleh dipakai key ini, dibatasi permission role pemiliknya
+	Created_at   time.Time  `json:"created_at"`
+	Expires_at   *time.Time `json:"expires_at,omitempty"`
+	Last_used_at *time.Time `json:"last_used_at,omitempty"`
+	Revoked_at   *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Status undangan, dihitung dari Accepted_at, Revoked_at dan Expires_at
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation adalah undangan signup dari admin untuk satu email, dengan role (User_type) dan
+// organisasi yang langsung diberikan ke akun barunya. Hanya hash SHA-256 dari token yang disimpan.
+type Invitation struct {
+	Invitation_id string     `bson:"_id" json:"invitation_id"`
+	Token_hash    string     `json:"-"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	Org_id        string     `json:"org_id,omitempty"`
+	Org_role      string     `json:"org_role,omitempty"` // role keanggotaan di Org_id
+	Invited_by    string     `json:"invited_by"`
+	Created_at    time.Time  `json:"created_at"`
+	Expires_at    time.Time  `json:"expires_at"`
+	Accepted_at   *time.Time `json:"accepted_at,omitempty"`
+	Accepted_by   string     `json:"accepted_by,omitempty"` // user_id akun yang dibuat dari undangan
+	Revoked_at    *time.Time `json:"revoked_at,omitempty"`
+	Status        string     `bson:"-" json:"status"` // diisi dengan StatusAt sebelum dikirim
+}
+
+// StatusAt mengembalikan status undangan pada waktu now
+func (i Invitation) StatusAt(now time.Time) string {
+	switch {
+	case i.Accepted_at != nil:
+		return InvitationAccepted
+	case i.Revoked_at != nil:
+		return InvitationRevoked
+	case !i.Expires_at.After(now):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
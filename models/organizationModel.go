@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Organization adalah tenant. User menjadi anggota satu atau lebih organisasi lewat User.Memberships.
+type Organization struct {
+	Org_id     string    `bson:"_id" json:"org_id"`
+	Name       *string   `json:"name" validate:"required,min=2,max=100"`
+	Created_by string    `json:"created_by"`
+	Created_at time.Time `json:"created_at"`
+	Updated_at time.Time `json:"updated_at"`
+}
+
+// Membership adalah keanggotaan user di satu organisasi beserta role-nya di organisasi itu.
+// Permission dari role ini hanya berlaku di dalam organisasi (claim org_permissions), bukan permission global.
+type Membership struct {
+	Org_id    string    `json:"org_id"`
+	Role      string    `json:"role"`
+	Joined_at time.Time `json:"joined_at"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Permission yang bisa diberikan ke role. Akses ke data milik sendiri (profil, password, sesi, MFA)
+// tidak butuh permission; permission di bawah ini untuk mengelola user lain.
+// Permission users:*, roles:* dan keys:* berasal dari role global (User_type) dan sengaja berlaku lintas
+// organisasi untuk admin platform; route-nya tidak dibatasi tenant walaupun sesi berada di organisasi.
+// Pengelolaan user di dalam satu organisasi memakai members:* lewat /org/members.
+const (
+	PermissionUsersRead     = "users:read"     // melihat daftar dan profil user lain
+	PermissionUsersWrite    = "users:write"    // mengubah profil user lain
+	PermissionUsersDelete   = "users:delete"   // menghapus dan memulihkan user lain
+	PermissionUsersSecurity = "users:security" // mencabut sesi, membuka kunci login dan reset MFA user lain
+	PermissionUsersInvite   = "users:invite"   // membuat, melihat dan mencabut undangan signup
+	PermissionRolesRead     = "roles:read"
+	PermissionRolesWrite    = "roles:write"
+	PermissionRolesAssign   = "roles:assign" // mengganti role (user_type) user
+	PermissionKeysRotate    = "keys:rotate"
+	// permission di dalam organisasi, diberikan lewat role keanggotaan (lihat Membership)
+	PermissionMembersRead  = "members:read"
+	PermissionMembersWrite = "members:write"
+)
+
+// Permissions adalah semua permission yang dikenal, role hanya boleh berisi permission dari daftar ini
+var Permissions = []string{
+	PermissionUsersRead, PermissionUsersWrite, PermissionUsersDelete, PermissionUsersSecurity, PermissionUsersInvite,
+	PermissionRolesRead, PermissionRolesWrite, PermissionRolesAssign, PermissionKeysRotate,
+	PermissionMembersRead, PermissionMembersWrite,
+}
+
+// Role bawaan, dibuat saat migrasi dan tidak boleh dihapus.
+// ORG_ADMIN dan ORG_MEMBER dipakai sebagai role keanggotaan organisasi.
+const (
+	RoleAdmin     = "ADMIN"
+	RoleUser      = "USER"
+	RoleOrgAdmin  = "ORG_ADMIN"
+	RoleOrgMember = "ORG_MEMBER"
+)
+
+// Role adalah kumpulan permission yang diberikan ke user lewat field User_type.
+// Permission dari role ikut tercantum di token saat login dan refresh.
+type Role struct {
+	Name        string    `bson:"_id" json:"name" validate:"required,min=2,max=50"`
+	Description string    `json:"description" validate:"max=200"`
+	Permissions []string  `json:"permissions"`
+	Created_at  time.Time `json:"created_at"`
+	Updated_at  time.Time `json:"updated_at"`
+}
+
+// DefaultRoles mengembalikan role bawaan: ADMIN dengan semua permission, USER tanpa permission tambahan,
+// serta ORG_ADMIN dan ORG_MEMBER untuk keanggotaan organisasi
+func DefaultRoles() []Role {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	return []Role{
+		{Name: RoleAdmin, Description: "full access to all users, roles and keys", Permissions: append([]string(nil), Permissions...), Created_at: now, Updated_at: now},
+		{Name: RoleUser, Description: "access to own account only", Permissions: []string{}, Created_at: now, Updated_at: now},
+		{Name: RoleOrgAdmin, Description: "manages members of an organization", Permissions: []string{PermissionMembersRead, PermissionMembersWrite}, Created_at: now, Updated_at: now},
+		{Name: RoleOrgMember, Description: "member of an organization", Permissions: []string{PermissionMembersRead}, Created_at: now, Updated_at: now},
+	}
+}
+
+// IsBuiltinRole mengecek apakah role termasuk role bawaan yang tidak boleh dihapus
+func IsBuiltinRole(name string) bool {
+	switch name {
+	case RoleAdmin, RoleUser, RoleOrgAdmin, RoleOrgMember:
+		return true
+	}
+	return false
+}
+
+// IsOrgRole mengecek apakah role boleh dipakai sebagai role keanggotaan organisasi
+func IsOrgRole(name string) bool {
+	return name == RoleOrgAdmin || name == RoleOrgMember
+}
+
+// IsPermission mengecek apakah permission termasuk daftar Permissions
+func IsPermission(permission string) bool {
+	for _, known := range Permissions {
+		if known == permission {
+			return true
+		}
+	}
+	return false
+}
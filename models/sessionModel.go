@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session adalah satu login di satu perangkat. Access token dan refresh token membawa session id,
+// sehingga setiap perangkat bisa dilihat dan diakhiri sendiri-sendiri.
+type Session struct {
+	ID                 primitive.ObjectID `bson:"_id" json:"-"`
+	Session_id         string             `json:"session_id"`
+	User_id            string             `json:"user_id"`
+	User_agent         string             `json:"user_agent"`
+	Ip                 string             `json:"ip"`
+	Org_id             string             `json:"org_id,omitempty"` // organisasi aktif sesi ini, tercantum di token sebagai tid
+	Refresh_token_hash string             `json:"-"`                // SHA-256 refresh token terakhir hasil rotasi, token sebelumnya dianggap reuse
+	Created_at         time.Time          `json:"created_at"`
+	Last_seen_at       time.Time          `json:"last_seen_at"`
+	Expires_at         time.Time          `json:"expires_at"`
+}
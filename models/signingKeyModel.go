@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SigningKey adalah kunci penandatangan token yang disimpan per versi (kid).
+// Kunci aktif adalah kunci terbaru yang belum di-retire; kunci yang sudah di-retire
+// masih diterima untuk verifikasi selama masa tenggang (grace window).
+// Dalam satu collection semua kunci memakai algoritma yang sama.
+type SigningKey struct {
+	Kid        string     `bson:"kid" json:"kid"`
+	Algorithm  string     `bson:"alg" json:"alg"`
+	Secret     []byte     `bson:"secret" json:"-"`                                  // secret HMAC atau private key Ed25519
+	Public_key []byte     `bson:"public_key,omitempty" json:"public_key,omitempty"` // hanya untuk kunci Ed25519, aman dipublikasikan
+	Created_at time.Time  `bson:"created_at" json:"created_at"`
+	Retired_at *time.Time `bson:"retired_at,omitempty" json:"retired_at,omitempty"`
+}
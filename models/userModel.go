@@ -11,18 +11,25 @@ import (
 // Dalam prakteknya, JSON lebih umum digunakan untuk pertukaran data antar sistem yang berbeda, sementara BSON sering digunakan dalam konteks database MongoDB untuk menyimpan dan mengambil data secara efisien.
 type User struct {
 	ID                 primitive.ObjectID `bson:"_id"`
-	First_name         *string            `json:"first_name" validate:"required,min=2,max=100"`   //validasi required yang di perlukan, min 2 karakter, max 100
-	Last_name          *string            `json:"last_name" validate:"required,min=2,max=100"`    //validasi required yang di perlukan, min 2 karakter, max 100
-	Password           *string            `json:"password" validate:"required,min=6"`             //validasi required yang di perlukan, min 2 karakter
-	Email              *string            `json:"email" validate:"email,required"`                //validasi required yang di perlukan email wajib
-	Phone              *string            `json:"phone" validate:"required"`                      //validasi required yang di perlukan password wajib
-	Token              *string            `json:"token"`                                          //validasi required yang di perlukan token wajib
-	User_type          *string            `json:"user_type" validate:"required,eq=ADMIN|eq=USER"` //penggunaan enum Ini adalah aturan validasi yang menentukan bahwa field ini harus sama dengan "ADMIN" atau harus diakhiri dengan "USER". Jadi, validasi akan berhasil jika nilai field ini adalah "ADMIN" atau nilainya diakhiri dengan "USER", seperti "SUPERUSER" atau "GUESTUSER". Namun, jika nilainya bukan "ADMIN" atau tidak diakhiri dengan "USER", maka validasi akan gagal.
-	Refresh_token      *string            `json:"refresh_token"`                                  //validasi required yang di perlukan refresh token wajib
-	Created_at         time.Time          `json:"created_at"`                                     //validasi required yang di perlukan created at wajib
-	Updated_at         time.Time          `json:"updated_at"`                                     //validasi required yang di perlukan updated at wajib
+	First_name         *string            `json:"first_name" validate:"required,min=2,max=100"` //validasi required yang di perlukan, min 2 karakter, max 100
+	Last_name          *string            `json:"last_name" validate:"required,min=2,max=100"`  //validasi required yang di perlukan, min 2 karakter, max 100
+	Password           *string            `json:"-" validate:"required"`                        //hash password, tidak pernah dikirim di respons; aturan password di passpolicy.Policy
+	Email              *string            `json:"email" validate:"email,required"`              //validasi required yang di perlukan email wajib
+	Phone              *string            `json:"phone" validate:"required,e164"`               //validasi required, disimpan dalam format E.164 (lihat phone.Normalize)
+	User_type          *string            `json:"user_type" validate:"required,min=2,max=50"`   //nama role (lihat models.Role), harus ada di RoleRepository; permission-nya ikut tercantum di token
+	Created_at         time.Time          `json:"created_at"`                                   //validasi required yang di perlukan created at wajib
+	Updated_at         time.Time          `json:"updated_at"`                                   //validasi required yang di perlukan updated at wajib
 	User_id            *string            `json:"user_id"`
-	Paseto_token       *string            `json:"paseto_token,omitempty"` //validasi required yang di perlukan user id wajib
-	PublicPaseto_token *string            `bson:"public_paseto_token,omitempty" json:"public_paseto_token"`
+	Email_verified     bool               `json:"email_verified"`       // true setelah link verifikasi email dibuka
+	Phone_verified     bool               `json:"phone_verified"`       // true setelah kode OTP SMS dikonfirmasi
+	Mfa_enabled        bool               `json:"mfa_enabled"`          // login butuh kode TOTP setelah password
+	Mfa_secret         *string            `json:"-"`                    // secret TOTP base32 yang sudah dikonfirmasi
+	Mfa_pending_secret *string            `json:"-"`                    // secret hasil enroll yang belum dikonfirmasi
+	Mfa_last_step      int64              `json:"-"`                    // langkah TOTP terakhir yang dipakai, mencegah kode dipakai ulang
+	Mfa_recovery_codes []string           `json:"-"`                    // hash SHA-256 recovery code yang belum dipakai
+	Deleted_at         *time.Time         `json:"deleted_at,omitempty"` // diisi saat akun dihapus (soft delete), dihapus permanen setelah masa tenggang
+	Password_history   []string           `json:"-"`                    // hash password sebelumnya, terbaru di depan
+	Memberships        []Membership       `json:"memberships"`          // organisasi tempat user menjadi anggota
+	Version            int64              `json:"-"`                    // naik setiap Update, sehingga perubahan dari request lain tidak tertimpa diam-diam
 	// PublicKey          []byte             `json:"public_key"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Tujuan token sekali pakai yang dikirim ke user
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePhoneVerification = "phone_verification"
+	TokenPurposeMFAChallenge      = "mfa_challenge"
+)
+
+// UserToken adalah token sekali pakai (mis. reset password) yang dikirim lewat email atau SMS.
+// Hanya hash SHA-256 dari token yang disimpan, token aslinya hanya ada di pesan yang dikirim.
+type UserToken struct {
+	Token_hash string    `bson:"_id" json:"-"`
+	Purpose    string    `json:"purpose"`
+	User_id    string    `json:"user_id"`
+	Created_at time.Time `json:"created_at"`
+	Expires_at time.Time `json:"expires_at"`
+	Attempts   int       `json:"attempts"` // percobaan gagal, dipakai untuk kode OTP yang pendek
+}
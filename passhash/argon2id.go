@@ -0,0 +1,113 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Parameter argon2id bawaan (RFC 9106, rekomendasi kedua dengan paralelisme lebih kecil)
+const (
+	DefaultArgon2Memory      = 64 * 1024 // KiB
+	DefaultArgon2Iterations  = 3
+	DefaultArgon2Parallelism = 2
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+// Argon2id menyimpan hash dalam format PHC: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2id struct {
+	Memory      uint32 // dalam KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2id mengembalikan Argon2id dengan parameter bawaan
+func DefaultArgon2id() Argon2id {
+	return Argon2id{Memory: DefaultArgon2Memory, Iterations: DefaultArgon2Iterations, Parallelism: DefaultArgon2Parallelism}
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key)), nil
+}
+
+func (a Argon2id) Verify(hash string, password string) error {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	// hash lama diperiksa dengan parameter yang tercatat di hash itu sendiri
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+func (a Argon2id) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (a Argon2id) NeedsRehash(hash string) bool {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params != a || len(salt) != argon2SaltLength || len(key) != argon2KeyLength
+}
+
+func (a Argon2id) validate() error {
+	switch {
+	case a.Iterations < 1:
+		return errors.New("passhash: argon2id iterations must be at least 1")
+	case a.Parallelism < 1:
+		return errors.New("passhash: argon2id parallelism must be at least 1")
+	case a.Memory < 8*uint32(a.Parallelism):
+		return errors.New("passhash: argon2id memory must be at least 8 KiB per thread")
+	}
+	return nil
+}
+
+// parseArgon2id membaca parameter, salt dan hash dari string PHC
+func parseArgon2id(hash string) (Argon2id, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2id{}, nil, nil, fmt.Errorf("passhash: unsupported argon2id version %q", parts[2])
+	}
+	var params Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("passhash: invalid argon2id parameters: %w", err)
+	}
+	// parameter nol membuat argon2 panic, jadi hash seperti itu ditolak sebelum dipakai
+	if err := params.validate(); err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	salt, err := argon2Encoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("passhash: invalid argon2id salt: %w", err)
+	}
+	key, err := argon2Encoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("passhash: invalid argon2id hash: %w", err)
+	}
+	return params, salt, key, nil
+}
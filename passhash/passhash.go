@@ -0,0 +1,76 @@
+package passhash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Algoritma hash password yang didukung
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	// ErrMismatch dikembalikan Verify jika password tidak cocok dengan hash
+	ErrMismatch = errors.New("passhash: password does not match")
+	// ErrUnknownHash dikembalikan jika tidak ada Hasher yang mengenali format hash
+	ErrUnknownHash = errors.New("passhash: unknown hash format")
+)
+
+// Hasher membuat dan memeriksa hash password untuk satu algoritma
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify mengembalikan ErrMismatch jika password salah
+	Verify(hash string, password string) error
+	// Identify bernilai true jika hash dibuat oleh algoritma ini
+	Identify(hash string) bool
+	// NeedsRehash bernilai true jika hash dibuat dengan parameter yang berbeda dari Hasher ini
+	NeedsRehash(hash string) bool
+}
+
+// PasswordHasher membuat hash dengan algoritma utama dan masih bisa memeriksa hash
+// dari algoritma lama, sehingga hash lama bisa diganti saat user berhasil login.
+type PasswordHasher struct {
+	current Hasher
+	hashers []Hasher
+}
+
+// New membuat PasswordHasher dengan current untuk hash baru.
+// Hash argon2id dan bcrypt apa pun parameternya tetap bisa diperiksa.
+func New(current Hasher) *PasswordHasher {
+	return &PasswordHasher{current: current, hashers: []Hasher{current, Argon2id{}, Bcrypt{}}}
+}
+
+// Hash membuat hash password dengan algoritma utama
+func (p *PasswordHasher) Hash(password string) (string, error) {
+	return p.current.Hash(password)
+}
+
+// Verify memeriksa password dengan algoritma yang sesuai format hash
+func (p *PasswordHasher) Verify(hash string, password string) error {
+	for _, hasher := range p.hashers {
+		if hasher.Identify(hash) {
+			return hasher.Verify(hash, password)
+		}
+	}
+	return ErrUnknownHash
+}
+
+// NeedsRehash bernilai true jika hash dibuat dengan algoritma lain atau parameter lama
+func (p *PasswordHasher) NeedsRehash(hash string) bool {
+	return !p.current.Identify(hash) || p.current.NeedsRehash(hash)
+}
+
+// NewHasher membuat Hasher berdasarkan nama algoritma dari konfigurasi
+func NewHasher(algorithm string, argon Argon2id, bcryptCost int) (Hasher, error) {
+	switch algorithm {
+	case AlgorithmArgon2id:
+		return argon, argon.validate()
+	case AlgorithmBcrypt:
+		hasher := Bcrypt{Cost: bcryptCost}
+		return hasher, hasher.validate()
+	default:
+		return nil, fmt.Errorf("passhash: unknown algorithm %q", algorithm)
+	}
+}
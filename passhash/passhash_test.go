@@ -0,0 +1,74 @@
+package passhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id memakai parameter kecil supaya test cepat
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	hash, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash %q is not in PHC format", hash)
+	}
+	if err := testArgon2id.Verify(hash, "correct horse"); err != nil {
+		t.Errorf("Verify with the right password: %v", err)
+	}
+	if err := testArgon2id.Verify(hash, "wrong horse"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Verify with the wrong password = %v, want ErrMismatch", err)
+	}
+	if testArgon2id.NeedsRehash(hash) {
+		t.Error("hash with current parameters needs rehash")
+	}
+
+	stronger := Argon2id{Memory: 128, Iterations: 2, Parallelism: 1}
+	if !stronger.NeedsRehash(hash) {
+		t.Error("hash with old parameters does not need rehash")
+	}
+	// hash lama tetap bisa diperiksa dengan parameter yang tercatat di dalamnya
+	if err := stronger.Verify(hash, "correct horse"); err != nil {
+		t.Errorf("Verify with other parameters: %v", err)
+	}
+}
+
+func TestArgon2idRejectsZeroParallelism(t *testing.T) {
+	if err := testArgon2id.Verify("$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5", "x"); err == nil {
+		t.Error("hash with p=0 was accepted")
+	}
+	if _, err := NewHasher(AlgorithmArgon2id, Argon2id{Memory: 64, Iterations: 1}, 0); err == nil {
+		t.Error("NewHasher accepted parallelism 0")
+	}
+}
+
+func TestPasswordHasherRehashesBcrypt(t *testing.T) {
+	legacy, err := Bcrypt{Cost: bcrypt.MinCost}.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher := New(testArgon2id)
+	if err := hasher.Verify(legacy, "correct horse"); err != nil {
+		t.Errorf("Verify bcrypt hash: %v", err)
+	}
+	if !hasher.NeedsRehash(legacy) {
+		t.Error("bcrypt hash does not need rehash to argon2id")
+	}
+
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Error("fresh hash needs rehash")
+	}
+	if err := hasher.Verify("plain-text", "plain-text"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("Verify unknown format = %v, want ErrUnknownHash", err)
+	}
+}
@@ -0,0 +1,78 @@
+package passpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixLength adalah panjang prefix hash SHA-1 seperti range API Have I Been Pwned
+const prefixLength = 5
+
+// Blocklist mengecek apakah password termasuk password yang diketahui bocor
+type Blocklist interface {
+	Contains(password string) bool
+}
+
+// PrefixBlocklist menyimpan hash SHA-1 password bocor dikelompokkan per 5 karakter pertama,
+// sama seperti range API Have I Been Pwned (k-anonymity), sehingga bisa dicek tanpa koneksi keluar.
+type PrefixBlocklist struct {
+	ranges map[string]map[string]struct{}
+}
+
+// LoadBlocklist membaca file berisi satu hash SHA-1 (hex) per baris, boleh diikuti ":jumlah"
+// seperti file unduhan Have I Been Pwned. Baris kosong dan yang diawali # diabaikan.
+func LoadBlocklist(path string) (*PrefixBlocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("passpolicy: %w", err)
+	}
+	defer file.Close()
+
+	list := &PrefixBlocklist{ranges: map[string]map[string]struct{}{}}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("passpolicy: %s:%d: not a SHA-1 hash", path, line)
+		}
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("passpolicy: %w", err)
+	}
+	return list, nil
+}
+
+func (l *PrefixBlocklist) add(hash string) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+	if l.ranges[prefix] == nil {
+		l.ranges[prefix] = map[string]struct{}{}
+	}
+	l.ranges[prefix][suffix] = struct{}{}
+}
+
+// Contains mencari suffix hash password di dalam range prefix-nya
+func (l *PrefixBlocklist) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := l.ranges[hash[:prefixLength]][hash[prefixLength:]]
+	return found
+}
+
+// Len mengembalikan jumlah hash di blocklist
+func (l *PrefixBlocklist) Len() int {
+	n := 0
+	for _, suffixes := range l.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package passpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Nilai bawaan policy password
+const (
+	DefaultMinLength  = 8
+	DefaultMaxLength  = 128
+	DefaultMinClasses = 2
+	DefaultHistory    = 5
+)
+
+// minPersonalLength adalah panjang minimal nama atau email yang dicek; nama yang lebih pendek terlalu sering cocok
+const minPersonalLength = 3
+
+// Policy adalah aturan untuk password baru
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MinClasses adalah jumlah minimal jenis karakter: huruf kecil, huruf besar, angka dan simbol
+	MinClasses int
+	// History adalah jumlah password terakhir (termasuk yang sedang dipakai) yang tidak boleh dipakai lagi
+	History int
+	// Breached berisi password yang diketahui bocor, nil jika tidak dicek
+	Breached Blocklist
+}
+
+// Default mengembalikan policy bawaan tanpa blocklist
+func Default() Policy {
+	return Policy{MinLength: DefaultMinLength, MaxLength: DefaultMaxLength, MinClasses: DefaultMinClasses, History: DefaultHistory}
+}
+
+// ViolationError berisi semua aturan yang dilanggar oleh password
+type ViolationError struct {
+	Problems []string
+}
+
+func (e *ViolationError) Error() string {
+	return "password " + strings.Join(e.Problems, "; ")
+}
+
+// Check memeriksa password terhadap policy. personal berisi email dan nama user yang tidak boleh
+// terkandung di password. Hasilnya *ViolationError jika ada aturan yang dilanggar.
+func (p Policy) Check(password string, personal ...string) error {
+	var problems []string
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+	if classes(password) < p.MinClasses {
+		problems = append(problems, fmt.Sprintf("must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinClasses))
+	}
+	if containsPersonal(password, personal) {
+		problems = append(problems, "must not contain your email or name")
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		problems = append(problems, "appears in a list of breached passwords")
+	}
+	if len(problems) > 0 {
+		return &ViolationError{Problems: problems}
+	}
+	return nil
+}
+
+// classes menghitung jenis karakter yang dipakai password
+func classes(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// containsPersonal mengecek email (bagian sebelum @) dan nama tanpa membedakan huruf besar/kecil
+func containsPersonal(password string, personal []string) bool {
+	password = strings.ToLower(password)
+	for _, value := range personal {
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			value = local
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		if utf8.RuneCountInString(value) >= minPersonalLength && strings.Contains(password, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package passpolicy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	policy := Default()
+	tests := []struct {
+		password string
+		personal []string
+		problems int
+	}{
+		{"Secret-pass1", nil, 0},
+		{"alllowercase", nil, 1},
+		{"Sh0rt", nil, 1},
+		{"short", nil, 2},
+		{strings.Repeat("Aa1", 50), nil, 1},
+		{"Bobby-2024", []string{"bobby@example.com", "Bob"}, 1},
+		// bagian nama yang lebih pendek dari minPersonalLength tidak dicek
+		{"Al-secret-1", []string{"Al"}, 0},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.password, tt.personal...)
+		var violation *ViolationError
+		switch {
+		case tt.problems == 0 && err != nil:
+			t.Errorf("Check(%q) = %v, want nil", tt.password, err)
+		case tt.problems > 0 && !errors.As(err, &violation):
+			t.Errorf("Check(%q) = %v, want a ViolationError", tt.password, err)
+		case tt.problems > 0 && len(violation.Problems) != tt.problems:
+			t.Errorf("Check(%q) problems = %q, want %d", tt.password, violation.Problems, tt.problems)
+		}
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1 dari "Password1!" (hex huruf kecil, dengan jumlah) dan "P@ssw0rd"
+	content := "# contoh\n\n" +
+		"32ca9fc1a0f5b6330e3f4c8c1bbecde9bedb9573:42\n" +
+		"21BD12DC183F740EE76F27B78EB39C8AD972A757\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", list.Len())
+	}
+
+	policy := Default()
+	policy.Breached = list
+	for _, password := range []string{"Password1!", "P@ssw0rd"} {
+		var violation *ViolationError
+		if err := policy.Check(password); !errors.As(err, &violation) || !strings.Contains(err.Error(), "breached") {
+			t.Errorf("Check(%q) = %v, want a breached password violation", password, err)
+		}
+	}
+	if err := policy.Check("Secret-pass1"); err != nil {
+		t.Errorf("Check of a password not in the list = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBlocklist(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("LoadBlocklist of an invalid line = %v, want an error with the line number", err)
+	}
+}
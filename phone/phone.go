@@ -0,0 +1,66 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultRegion adalah region yang dipakai untuk nomor tanpa kode negara (mis. "0812...")
+const DefaultRegion = "ID"
+
+// callingCodes adalah kode negara per region yang didukung
+var callingCodes = map[string]string{
+	"ID": "62",
+}
+
+var (
+	// ErrInvalidPhone dikembalikan ketika nomor tidak bisa diubah menjadi format E.164
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrUnsupportedRegion dikembalikan ketika region tidak ada di callingCodes
+	ErrUnsupportedRegion = errors.New("unsupported phone region")
+)
+
+// Normalize mengubah nomor telepon menjadi format E.164 (mis. "+62812345678").
+// Spasi, tanda hubung, titik dan kurung diabaikan; nomor nasional ("0812...") memakai kode negara region.
+func Normalize(raw string, region string) (string, error) {
+	code, ok := callingCodes[region]
+	if !ok {
+		return "", ErrUnsupportedRegion
+	}
+
+	var digits strings.Builder
+	trimmed := strings.TrimSpace(raw)
+	for i, r := range trimmed {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case strings.HasPrefix(number, "0"):
+		number = code + number[1:]
+	case !strings.HasPrefix(number, code):
+		number = code + number
+	}
+	// "+62 0812..." sering ditulis dengan angka 0 nasional yang tersisa
+	if strings.HasPrefix(number, code+"0") {
+		number = code + number[len(code)+1:]
+	}
+
+	// E.164: maksimal 15 digit dan tidak diawali 0
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + number, nil
+}
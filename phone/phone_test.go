@@ -0,0 +1,37 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw    string
+		region string
+		want   string
+		err    error
+	}{
+		{"0812-3456-7890", "ID", "+6281234567890", nil},
+		{" 0812 3456 7890 ", "ID", "+6281234567890", nil},
+		{"+62 812 3456 7890", "ID", "+6281234567890", nil},
+		{"+62 (0)812.3456.7890", "ID", "+6281234567890", nil},
+		{"0062812345678", "ID", "+62812345678", nil},
+		{"6281234567", "ID", "+6281234567", nil},
+		{"812345678", "ID", "+62812345678", nil},
+		{"+1 415 555 0100", "ID", "+14155550100", nil},
+		{"", "ID", "", ErrInvalidPhone},
+		{"0812", "ID", "", ErrInvalidPhone},
+		{"0812abc45678", "ID", "", ErrInvalidPhone},
+		{"0812+345678", "ID", "", ErrInvalidPhone},
+		{"+0812345678", "ID", "", ErrInvalidPhone},
+		{"+1234567890123456", "ID", "", ErrInvalidPhone},
+		{"0812345678", "XX", "", ErrUnsupportedRegion},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.raw, tt.region)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Normalize(%q, %q) = %q, %v; want %q, %v", tt.raw, tt.region, got, err, tt.want, tt.err)
+		}
+	}
+}
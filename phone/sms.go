@@ -0,0 +1,25 @@
+package phone
+
+import (
+	"context"
+	"log"
+)
+
+// SMSSender mengirim SMS ke nomor dalam format E.164
+type SMSSender interface {
+	Send(ctx context.Context, to string, body string) error
+}
+
+// LogSender hanya menulis SMS ke log, untuk development tanpa provider SMS
+type LogSender struct {
+	Logger *log.Logger
+}
+
+func NewLogSender(logger *log.Logger) *LogSender {
+	return &LogSender{Logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, to string, body string) error {
+	s.Logger.Printf("SMS to %s: %s", to, body)
+	return nil
+}
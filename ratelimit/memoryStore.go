@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore adalah Store di memori, cocok untuk satu instance atau untuk development
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]bucket
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]bucket{}}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(policy.Limit), updatedAt: now}
+	}
+	b.tokens = math.Min(float64(policy.Limit), b.tokens+now.Sub(b.updatedAt).Seconds()*policy.rate())
+	b.updatedAt = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	// setelah Period tanpa request bucket pasti penuh lagi, jadi boleh dibuang
+	b.expiresAt = now.Add(policy.Period)
+	s.buckets[key] = b
+	return policy.result(allowed, b.tokens), nil
+}
+
+// prune membuang bucket yang sudah kedaluwarsa, paling sering sekali per menit, dipanggil dengan lock dipegang
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore adalah Store di MongoDB. Isi bucket dihitung atomik dengan update pipeline (MongoDB 4.2+),
+// dan dokumen dihapus otomatis oleh TTL index pada expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+type bucketDocument struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (s *MongoStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	if err := s.ensureIndexes(ctx); err != nil {
+		return Result{}, err
+	}
+	limit := float64(policy.Limit)
+	perMillisecond := policy.rate() / 1000
+	refilled := bson.M{"$min": bson.A{limit, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", limit}},
+		bson.M{"$multiply": bson.A{bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}}, perMillisecond}},
+	}}}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updated_at": now, "expires_at": now.Add(policy.Period)}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed": hasToken,
+			"tokens":  bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var doc bucketDocument
+	if err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc); err != nil {
+		return Result{}, err
+	}
+	return policy.result(doc.Allowed, doc.Tokens), nil
+}
+
+// ensureIndexes membuat TTL index pada expires_at saat request pertama dibatasi
+func (s *MongoStore) ensureIndexes(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexed {
+		return nil
+	}
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+	s.indexed = true
+	return nil
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy adalah token bucket berisi Limit token yang terisi penuh kembali dalam Period.
+// Setiap request mengambil satu token; request ditolak ketika bucket kosong.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Result adalah hasil pengambilan token untuk satu request
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // sampai bucket penuh lagi
+	RetryAfter time.Duration // sampai satu token tersedia, hanya jika ditolak
+}
+
+// Store menyimpan isi bucket per key. Ada implementasi MongoDB (dipakai bersama semua instance) dan memori.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// Nama policy per route
+const (
+	PolicySignup             = "signup"
+	PolicyLogin              = "login"
+	PolicyRefresh            = "refresh"
+	PolicyUsers              = "users"
+	PolicyForgotPassword     = "forgot_password"
+	PolicyResetPassword      = "reset_password"
+	PolicyResendVerification = "resend_verification"
+)
+
+// DefaultPolicies dipakai untuk route yang tidak diatur lewat RATE_LIMITS.
+// Route yang mengirim email dibatasi ketat per IP, di samping jeda per email di controller.
+var DefaultPolicies = map[string]Policy{
+	PolicySignup:             {Limit: 10, Period: time.Hour},
+	PolicyLogin:              {Limit: 10, Period: time.Minute},
+	PolicyRefresh:            {Limit: 30, Period: time.Minute},
+	PolicyUsers:              {Limit: 60, Period: time.Minute},
+	PolicyForgotPassword:     {Limit: 5, Period: 15 * time.Minute},
+	PolicyResetPassword:      {Limit: 10, Period: 15 * time.Minute},
+	PolicyResendVerification: {Limit: 5, Period: 15 * time.Minute},
+}
+
+// ParsePolicies membaca daftar policy seperti "login=10/1m,signup=5/1h" di atas DefaultPolicies.
+// Limit 0 mematikan rate limit untuk route tersebut. Nama yang tidak ada di DefaultPolicies ditolak,
+// supaya salah ketik tidak diam-diam membiarkan route memakai policy bawaan.
+func ParsePolicies(spec string) (map[string]Policy, error) {
+	policies := map[string]Policy{}
+	for name, policy := range DefaultPolicies {
+		policies[name] = policy
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		limit, period, ok2 := strings.Cut(value, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("rate limit %q: want name=limit/period", item)
+		}
+		name = strings.TrimSpace(name)
+		if _, known := DefaultPolicies[name]; !known {
+			return nil, fmt.Errorf("rate limit %q: unknown policy %q", item, name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid limit", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid period", item)
+		}
+		policies[name] = Policy{Limit: n, Period: d}
+	}
+	return policies, nil
+}
+
+// String menulis policy dalam format header RateLimit-Policy, mis. "10;w=60"
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(math.Ceil(p.Period.Seconds())))
+}
+
+// rate adalah jumlah token yang terisi per detik
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// result menyusun Result dari isi bucket setelah request diputuskan
+func (p Policy) result(allowed bool, tokens float64) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(p.Limit) - tokens) / p.rate() * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / p.rate() * float64(time.Second))
+	}
+	return result
+}
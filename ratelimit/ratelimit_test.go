@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRefill(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	policy := Policy{Limit: 3, Period: 3 * time.Second} // satu token per detik
+	now := time.Unix(1700000000, 0)
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "k", policy, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("take %d: %+v", 3-i, result)
+		}
+	}
+	result, _ := store.Take(ctx, "k", policy, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("empty bucket: %+v, want denied with retry after 1s", result)
+	}
+	// key lain punya bucket sendiri
+	if result, _ := store.Take(ctx, "other", policy, now); !result.Allowed {
+		t.Fatal("other key was limited")
+	}
+
+	// setengah detik belum cukup untuk satu token, satu detik cukup
+	if result, _ = store.Take(ctx, "k", policy, now.Add(500*time.Millisecond)); result.Allowed {
+		t.Fatalf("allowed after 0.5s: %+v", result)
+	}
+	if result, _ = store.Take(ctx, "k", policy, now.Add(time.Second)); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("after 1s: %+v, want one token", result)
+	}
+
+	// setelah lama tidak dipakai bucket penuh lagi, tidak lebih dari Limit
+	if result, _ = store.Take(ctx, "k", policy, now.Add(time.Hour)); !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("after refill: %+v, want remaining 2", result)
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies(" login=5/30s, signup=0/1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := policies[PolicyLogin]; got != (Policy{Limit: 5, Period: 30 * time.Second}) {
+		t.Errorf("login = %+v", got)
+	}
+	if got := policies[PolicySignup]; got.Limit != 0 {
+		t.Errorf("signup = %+v, want disabled", got)
+	}
+	if got := policies[PolicyUsers]; got != DefaultPolicies[PolicyUsers] {
+		t.Errorf("users = %+v, want default", got)
+	}
+
+	for _, spec := range []string{"lgoin=5/1m", "login=5", "login=-1/1m", "login=5/0s", "login=x/1m"} {
+		if _, err := ParsePolicies(spec); err == nil {
+			t.Errorf("ParsePolicies(%q) accepted", spec)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"time"
+)
+
+// ErrAPIKeyNotFound dikembalikan ketika API key tidak ada, bukan milik user atau sudah dicabut
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKeyRepository menyimpan API key pribadi user
+type APIKeyRepository interface {
+	Create(ctx context.Context, key models.APIKey) error
+	FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// ListByUser mengembalikan API key user yang belum dicabut, terbaru lebih dulu
+	ListByUser(ctx context.Context, userId string) ([]models.APIKey, error)
+	// Revoke mencabut API key milik userId yang belum dicabut
+	Revoke(ctx context.Context, userId string, keyId string, at time.Time) error
+	// RevokeAllByUser mencabut semua API key milik userId yang belum dicabut
+	RevokeAllByUser(ctx context.Context, userId string, at time.Time) error
+	UpdateLastUsed(ctx context.Context, keyId string, at time.Time) error
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"time"
+)
+
+// ErrInvitationNotFound dikembalikan ketika undangan tidak ada atau statusnya tidak sesuai
+// (mis. Accept untuk undangan yang sudah dipakai)
+var ErrInvitationNotFound = errors.New("invitation not found")
+
+// InvitationRepository menyimpan undangan signup
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation models.Invitation) error
+	FindByID(ctx context.Context, invitationId string) (models.Invitation, error)
+	// FindPending mengembalikan undangan dengan token tersebut yang masih pending pada waktu now
+	FindPending(ctx context.Context, tokenHash string, now time.Time) (models.Invitation, error)
+	// List mengembalikan undangan terbaru lebih dulu beserta jumlah totalnya; status kosong berarti semua status
+	List(ctx context.Context, status string, now time.Time, offset int, limit int) ([]models.Invitation, int64, error)
+	// Accept menandai undangan pending sebagai dipakai oleh userId secara atomik,
+	// sehingga satu undangan hanya bisa dipakai sekali
+	Accept(ctx context.Context, tokenHash string, userId string, now time.Time) (models.Invitation, error)
+	// Reopen membatalkan Accept jika akun dari undangan ternyata gagal dibuat
+	Reopen(ctx context.Context, invitationId string) error
+	// Revoke mencabut undangan yang belum dipakai atau dicabut
+	Revoke(ctx context.Context, invitationId string, at time.Time) error
+}
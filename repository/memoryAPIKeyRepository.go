@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryAPIKeyRepository menyimpan API key di memori dan aman dipakai dari banyak goroutine
+type MemoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]models.APIKey // key: Key_id
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: map[string]models.APIKey{}}
+}
+
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// meniru unique index prefix pada MongoDB
+	for _, other := range r.keys {
+		if other.Prefix == key.Prefix {
+			return &DuplicateError{Field: "prefix"}
+		}
+	}
+	r.keys[key.Key_id] = cloneAPIKey(key)
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+func (r *MemoryAPIKeyRepository) ListByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	r.mu.RLock()
+	keys := []models.APIKey{}
+	for _, key := range r.keys {
+		if key.User_id == userId && key.Revoked_at == nil {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+	r.mu.RUnlock()
+
+	// urutan sama dengan implementasi MongoDB: yang terbaru tampil lebih dulu
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Created_at.After(keys[j].Created_at) })
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(ctx context.Context, userId string, keyId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyId]
+	if !ok || key.User_id != userId || key.Revoked_at != nil {
+		return ErrAPIKeyNotFound
+	}
+	key.Revoked_at = &at
+	r.keys[keyId] = key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) RevokeAllByUser(ctx context.Context, userId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for keyId, key := range r.keys {
+		if key.User_id == userId && key.Revoked_at == nil {
+			revokedAt := at
+			key.Revoked_at = &revokedAt
+			r.keys[keyId] = key
+		}
+	}
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) UpdateLastUsed(ctx context.Context, keyId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.keys[keyId]; ok {
+		key.Last_used_at = &at
+		r.keys[keyId] = key
+	}
+	return nil
+}
+
+// cloneAPIKey menyalin field pointer dan slice supaya perubahan oleh pemanggil tidak ikut mengubah data tersimpan
+func cloneAPIKey(key models.APIKey) models.APIKey {
+	clone := key
+	clone.Scopes = append([]string{}, key.Scopes...)
+	for _, field := range []**time.Time{&clone.Expires_at, &clone.Last_used_at, &clone.Revoked_at} {
+		if *field != nil {
+			value := **field
+			*field = &value
+		}
+	}
+	return clone
+}
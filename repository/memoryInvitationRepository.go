@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryInvitationRepository menyimpan undangan di memori dan aman dipakai dari banyak goroutine
+type MemoryInvitationRepository struct {
+	mu          sync.RWMutex
+	invitations map[string]models.Invitation // key: Invitation_id
+}
+
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{invitations: map[string]models.Invitation{}}
+}
+
+func (r *MemoryInvitationRepository) Create(ctx context.Context, invitation models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invitations[invitation.Invitation_id] = cloneInvitation(invitation)
+	return nil
+}
+
+func (r *MemoryInvitationRepository) FindByID(ctx context.Context, invitationId string) (models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	invitation, ok := r.invitations[invitationId]
+	if !ok {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	return cloneInvitation(invitation), nil
+}
+
+func (r *MemoryInvitationRepository) FindPending(ctx context.Context, tokenHash string, now time.Time) (models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	invitation, ok := r.findPending(tokenHash, now)
+	if !ok {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	return cloneInvitation(invitation), nil
+}
+
+func (r *MemoryInvitationRepository) List(ctx context.Context, status string, now time.Time, offset int, limit int) ([]models.Invitation, int64, error) {
+	r.mu.RLock()
+	all := make([]models.Invitation, 0, len(r.invitations))
+	for _, invitation := range r.invitations {
+		if status == "" || invitation.StatusAt(now) == status {
+			all = append(all, cloneInvitation(invitation))
+		}
+	}
+	r.mu.RUnlock()
+
+	// urutan sama dengan implementasi MongoDB: yang terbaru tampil lebih dulu
+	sort.Slice(all, func(i, j int) bool { return all[i].Created_at.After(all[j].Created_at) })
+	total := int64(len(all))
+	if offset > len(all) {
+		offset = len(all)
+	}
+	end := offset + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *MemoryInvitationRepository) Accept(ctx context.Context, tokenHash string, userId string, now time.Time) (models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invitation, ok := r.findPending(tokenHash, now)
+	if !ok {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	invitation.Accepted_at = &now
+	invitation.Accepted_by = userId
+	r.invitations[invitation.Invitation_id] = invitation
+	return cloneInvitation(invitation), nil
+}
+
+func (r *MemoryInvitationRepository) Reopen(ctx context.Context, invitationId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invitation, ok := r.invitations[invitationId]
+	if !ok || invitation.Accepted_at == nil {
+		return ErrInvitationNotFound
+	}
+	invitation.Accepted_at = nil
+	invitation.Accepted_by = ""
+	r.invitations[invitationId] = invitation
+	return nil
+}
+
+func (r *MemoryInvitationRepository) Revoke(ctx context.Context, invitationId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invitation, ok := r.invitations[invitationId]
+	if !ok || invitation.Accepted_at != nil || invitation.Revoked_at != nil {
+		return ErrInvitationNotFound
+	}
+	invitation.Revoked_at = &at
+	r.invitations[invitationId] = invitation
+	return nil
+}
+
+// findPending dipanggil dengan lock dipegang
+func (r *MemoryInvitationRepository) findPending(tokenHash string, now time.Time) (models.Invitation, bool) {
+	for _, invitation := range r.invitations {
+		if invitation.Token_hash == tokenHash && invitation.StatusAt(now) == models.InvitationPending {
+			return invitation, true
+		}
+	}
+	return models.Invitation{}, false
+}
+
+// cloneInvitation menyalin field pointer supaya perubahan oleh pemanggil tidak ikut mengubah data tersimpan
+func cloneInvitation(invitation models.Invitation) models.Invitation {
+	clone := invitation
+	for _, field := range []**time.Time{&clone.Accepted_at, &clone.Revoked_at} {
+		if *field != nil {
+			value := **field
+			*field = &value
+		}
+	}
+	return clone
+}
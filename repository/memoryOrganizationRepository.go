@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+)
+
+// MemoryOrganizationRepository menyimpan organisasi di memori dan aman dipakai dari banyak goroutine
+type MemoryOrganizationRepository struct {
+	mu   sync.RWMutex
+	orgs map[string]models.Organization
+}
+
+func NewMemoryOrganizationRepository() *MemoryOrganizationRepository {
+	return &MemoryOrganizationRepository{orgs: map[string]models.Organization{}}
+}
+
+func (r *MemoryOrganizationRepository) Create(ctx context.Context, org models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orgs[org.Org_id] = cloneOrganization(org)
+	return nil
+}
+
+func (r *MemoryOrganizationRepository) FindByID(ctx context.Context, orgId string) (models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	org, ok := r.orgs[orgId]
+	if !ok {
+		return models.Organization{}, ErrOrganizationNotFound
+	}
+	return cloneOrganization(org), nil
+}
+
+func (r *MemoryOrganizationRepository) FindByIDs(ctx context.Context, orgIds []string) ([]models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orgs := []models.Organization{}
+	for _, orgId := range orgIds {
+		if org, ok := r.orgs[orgId]; ok {
+			orgs = append(orgs, cloneOrganization(org))
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return *orgs[i].Name < *orgs[j].Name })
+	return orgs, nil
+}
+
+// cloneOrganization menyalin field pointer supaya perubahan oleh pemanggil tidak ikut mengubah data tersimpan
+func cloneOrganization(org models.Organization) models.Organization {
+	if org.Name != nil {
+		name := *org.Name
+		org.Name = &name
+	}
+	return org
+}
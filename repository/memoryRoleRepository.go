@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+)
+
+// MemoryRoleRepository menyimpan role di memori dan aman dipakai dari banyak goroutine
+type MemoryRoleRepository struct {
+	mu    sync.Mutex
+	roles map[string]models.Role
+}
+
+// NewMemoryRoleRepository membuat repository berisi roles awal, biasanya models.DefaultRoles()
+func NewMemoryRoleRepository(roles ...models.Role) *MemoryRoleRepository {
+	r := &MemoryRoleRepository{roles: map[string]models.Role{}}
+	for _, role := range roles {
+		r.roles[role.Name] = cloneRole(role)
+	}
+	return r
+}
+
+func (r *MemoryRoleRepository) FindByName(ctx context.Context, name string) (models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.roles[name]
+	if !ok {
+		return models.Role{}, ErrRoleNotFound
+	}
+	return cloneRole(role), nil
+}
+
+func (r *MemoryRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	roles := make([]models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, cloneRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *MemoryRoleRepository) Create(ctx context.Context, role models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[role.Name]; ok {
+		return ErrRoleExists
+	}
+	r.roles[role.Name] = cloneRole(role)
+	return nil
+}
+
+func (r *MemoryRoleRepository) Update(ctx context.Context, role models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[role.Name]; !ok {
+		return ErrRoleNotFound
+	}
+	r.roles[role.Name] = cloneRole(role)
+	return nil
+}
+
+func (r *MemoryRoleRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[name]; !ok {
+		return ErrRoleNotFound
+	}
+	delete(r.roles, name)
+	return nil
+}
+
+// cloneRole menyalin slice permission supaya perubahan oleh pemanggil tidak ikut mengubah data tersimpan
+func cloneRole(role models.Role) models.Role {
+	role.Permissions = append([]string{}, role.Permissions...)
+	return role
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionRepository menyimpan sesi di memori dan aman dipakai dari banyak goroutine
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session // key: Session_id
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: map[string]models.Session{}}
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, session models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.Session_id] = session
+	return nil
+}
+
+func (r *MemorySessionRepository) FindByID(ctx context.Context, sessionId string) (models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionId]
+	if !ok {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (r *MemorySessionRepository) UpdateLastSeen(ctx context.Context, sessionId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionId]; ok {
+		session.Last_seen_at = at
+		r.sessions[sessionId] = session
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) ReplaceRefreshToken(ctx context.Context, sessionId string, oldHash string, newHash string, at time.Time, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionId]
+	if !ok || session.Refresh_token_hash != oldHash {
+		return false, nil
+	}
+	session.Refresh_token_hash = newHash
+	session.Last_seen_at = at
+	session.Expires_at = expiresAt
+	r.sessions[sessionId] = session
+	return true, nil
+}
+
+func (r *MemorySessionRepository) ListByUser(ctx context.Context, userId string, now time.Time) ([]models.Session, error) {
+	r.mu.Lock()
+	sessions := []models.Session{}
+	for id, session := range r.sessions {
+		if now.After(session.Expires_at) {
+			delete(r.sessions, id) // pengganti TTL index
+			continue
+		}
+		if session.User_id == userId {
+			sessions = append(sessions, session)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Last_seen_at.After(sessions[j].Last_seen_at) })
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) Delete(ctx context.Context, userId string, sessionId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionId]
+	if !ok || session.User_id != userId {
+		return ErrSessionNotFound
+	}
+	delete(r.sessions, sessionId)
+	return nil
+}
+
+func (r *MemorySessionRepository) DeleteByUser(ctx context.Context, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.sessions {
+		if session.User_id == userId {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository menyimpan user di memori dan aman dipakai dari banyak goroutine
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User // key: User_id
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[string]models.User{}}
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findFirst(func(user models.User) bool {
+		return user.Email != nil && *user.Email == email && user.Deleted_at == nil && inTenant(ctx, user)
+	})
+}
+
+func (r *MemoryUserRepository) FindByPhone(ctx context.Context, phone string) (models.User, error) {
+	return r.findFirst(func(user models.User) bool {
+		return user.Phone != nil && *user.Phone == phone && user.Deleted_at == nil && inTenant(ctx, user)
+	})
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[userId]
+	if !ok || user.Deleted_at != nil || !inTenant(ctx, user) {
+		return models.User{}, ErrUserNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[userKey(user)]; ok {
+		return &DuplicateError{Field: "user_id"}
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	r.users[userKey(user)] = cloneUser(user)
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.users[userKey(user)]
+	if !ok || stored.Deleted_at != nil || !inTenant(ctx, stored) {
+		return ErrUserNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrUserConflict
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	// sama dengan implementasi MongoDB: keanggotaan dan soft delete tidak ikut ditimpa
+	updated := cloneUser(user)
+	updated.Memberships = stored.Memberships
+	updated.Deleted_at = stored.Deleted_at
+	updated.Version = stored.Version + 1
+	r.users[userKey(user)] = updated
+	return nil
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context, offset int, limit int) ([]models.User, int64, error) {
+	r.mu.RLock()
+	all := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		if user.Deleted_at == nil && inTenant(ctx, user) {
+			all = append(all, cloneUser(user))
+		}
+	}
+	r.mu.RUnlock()
+
+	// urutan sama dengan implementasi MongoDB: yang dibuat lebih dulu tampil lebih dulu
+	sort.Slice(all, func(i, j int) bool { return all[i].Created_at.Before(all[j].Created_at) })
+	total := int64(len(all))
+	if offset > len(all) {
+		offset = len(all)
+	}
+	end := offset + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userId]; !ok || !inTenant(ctx, user) {
+		return ErrUserNotFound
+	}
+	delete(r.users, userId)
+	return nil
+}
+
+func (r *MemoryUserRepository) SoftDelete(ctx context.Context, userId string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userId]
+	if !ok || user.Deleted_at != nil || !inTenant(ctx, user) {
+		return ErrUserNotFound
+	}
+	user.Deleted_at = &at
+	user.Updated_at = at
+	r.users[userId] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) Restore(ctx context.Context, userId string, deletedAfter time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userId]
+	if !ok || user.Deleted_at == nil || user.Deleted_at.Before(deletedAfter) || !inTenant(ctx, user) {
+		return ErrUserNotFound
+	}
+	user.Deleted_at = nil
+	user.Updated_at = time.Now()
+	r.users[userId] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for id, user := range r.users {
+		if user.Deleted_at != nil && user.Deleted_at.Before(deletedBefore) && inTenant(ctx, user) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryUserRepository) AddMembership(ctx context.Context, userId string, membership models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userId]
+	if !ok || user.Deleted_at != nil || !inOrgTenant(ctx, membership.Org_id) {
+		return ErrUserNotFound
+	}
+	if membershipIndex(user, membership.Org_id) >= 0 {
+		return ErrMembershipExists
+	}
+	user.Memberships = append(append([]models.Membership(nil), user.Memberships...), membership)
+	r.users[userId] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) SetMembershipRole(ctx context.Context, userId string, orgId string, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userId]
+	if !ok || user.Deleted_at != nil || !inOrgTenant(ctx, orgId) {
+		return ErrUserNotFound
+	}
+	i := membershipIndex(user, orgId)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	if role != models.RoleOrgAdmin && r.lastOrgAdmin(user, orgId) {
+		return ErrLastOrgAdmin
+	}
+	user.Memberships = append([]models.Membership(nil), user.Memberships...)
+	user.Memberships[i].Role = role
+	r.users[userId] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) RemoveMembership(ctx context.Context, userId string, orgId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userId]
+	if !ok || !inOrgTenant(ctx, orgId) {
+		return ErrUserNotFound
+	}
+	i := membershipIndex(user, orgId)
+	if i < 0 {
+		return ErrUserNotFound
+	}
+	if r.lastOrgAdmin(user, orgId) {
+		return ErrLastOrgAdmin
+	}
+	memberships := append([]models.Membership(nil), user.Memberships[:i]...)
+	user.Memberships = append(memberships, user.Memberships[i+1:]...)
+	r.users[userId] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) CountMembers(ctx context.Context, orgId string, role string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !inOrgTenant(ctx, orgId) {
+		return 0, nil
+	}
+	return r.countMembers(orgId, role), nil
+}
+
+// countMembers menghitung anggota aktif orgId dengan role tersebut, dipanggil dengan lock dipegang
+func (r *MemoryUserRepository) countMembers(orgId string, role string) int64 {
+	var count int64
+	for _, user := range r.users {
+		if i := membershipIndex(user, orgId); i >= 0 && user.Deleted_at == nil && user.Memberships[i].Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+// lastOrgAdmin bernilai true jika user aktif dan satu-satunya ORG_ADMIN di orgId, dipanggil dengan lock dipegang.
+// Pengecekan dan perubahan berada di bawah lock yang sama, sehingga tidak bisa diselip perubahan lain.
+func (r *MemoryUserRepository) lastOrgAdmin(user models.User, orgId string) bool {
+	i := membershipIndex(user, orgId)
+	return i >= 0 && user.Deleted_at == nil && user.Memberships[i].Role == models.RoleOrgAdmin && r.countMembers(orgId, models.RoleOrgAdmin) <= 1
+}
+
+// inTenant meniru filter memberships.org_id pada MongoDB jika ctx dibuat dengan WithTenant
+func inTenant(ctx context.Context, user models.User) bool {
+	orgId := TenantFrom(ctx)
+	return orgId == "" || membershipIndex(user, orgId) >= 0
+}
+
+func membershipIndex(user models.User, orgId string) int {
+	for i, membership := range user.Memberships {
+		if membership.Org_id == orgId {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *MemoryUserRepository) findFirst(match func(models.User) bool) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if match(user) {
+			return cloneUser(user), nil
+		}
+	}
+	return models.User{}, ErrUserNotFound
+}
+
+// checkUnique meniru unique index email dan phone pada MongoDB, dipanggil dengan lock dipegang
+func (r *MemoryUserRepository) checkUnique(user models.User) error {
+	for id, other := range r.users {
+		if id == userKey(user) {
+			continue
+		}
+		if sameValue(other.Email, user.Email) {
+			return &DuplicateError{Field: "email"}
+		}
+		if sameValue(other.Phone, user.Phone) {
+			return &DuplicateError{Field: "phone"}
+		}
+	}
+	return nil
+}
+
+func sameValue(a *string, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+func userKey(user models.User) string {
+	if user.User_id == nil {
+		return ""
+	}
+	return *user.User_id
+}
+
+// cloneUser menyalin field pointer supaya perubahan oleh pemanggil tidak ikut mengubah data tersimpan
+func cloneUser(user models.User) models.User {
+	clone := user
+	for _, field := range []**string{
+		&clone.First_name, &clone.Last_name, &clone.Password, &clone.Email, &clone.Phone,
+		&clone.User_type, &clone.User_id, &clone.Mfa_secret, &clone.Mfa_pending_secret,
+	} {
+		if *field != nil {
+			value := **field
+			*field = &value
+		}
+	}
+	clone.Mfa_recovery_codes = append([]string(nil), user.Mfa_recovery_codes...)
+	clone.Password_history = append([]string(nil), user.Password_history...)
+	clone.Memberships = append([]models.Membership(nil), user.Memberships...)
+	if clone.Deleted_at != nil {
+		deletedAt := *clone.Deleted_at
+		clone.Deleted_at = &deletedAt
+	}
+	return clone
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sync"
+	"time"
+)
+
+// MemoryUserTokenRepository menyimpan token sekali pakai di memori dan aman dipakai dari banyak goroutine
+type MemoryUserTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.UserToken // key: Token_hash
+}
+
+func NewMemoryUserTokenRepository() *MemoryUserTokenRepository {
+	return &MemoryUserTokenRepository{tokens: map[string]models.UserToken{}}
+}
+
+func (r *MemoryUserTokenRepository) Create(ctx context.Context, token models.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.Token_hash] = token
+	return nil
+}
+
+func (r *MemoryUserTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || !token.Expires_at.After(now) {
+		return models.UserToken{}, ErrUserTokenNotFound
+	}
+	delete(r.tokens, tokenHash)
+	return token, nil
+}
+
+func (r *MemoryUserTokenRepository) FindLatest(ctx context.Context, userId string, purpose string) (models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest models.UserToken
+	found := false
+	for _, token := range r.tokens {
+		if token.User_id == userId && token.Purpose == purpose && (!found || token.Created_at.After(latest.Created_at)) {
+			latest = token
+			found = true
+		}
+	}
+	if !found {
+		return models.UserToken{}, ErrUserTokenNotFound
+	}
+	return latest, nil
+}
+
+func (r *MemoryUserTokenRepository) AddFailedAttempt(ctx context.Context, userId string, purpose string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempts := 0
+	found := false
+	for hash, token := range r.tokens {
+		if token.User_id == userId && token.Purpose == purpose {
+			token.Attempts++
+			r.tokens[hash] = token
+			if token.Attempts > attempts {
+				attempts = token.Attempts
+			}
+			found = true
+		}
+	}
+	if !found {
+		return 0, ErrUserTokenNotFound
+	}
+	return attempts, nil
+}
+
+func (r *MemoryUserTokenRepository) DeleteByUser(ctx context.Context, userId string, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.tokens {
+		if token.User_id == userId && token.Purpose == purpose {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
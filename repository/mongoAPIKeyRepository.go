@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAPIKeyRepository menyimpan API key di collection MongoDB dengan key_id sebagai _id
+type MongoAPIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAPIKeyRepository(collection *mongo.Collection) *MongoAPIKeyRepository {
+	return &MongoAPIKeyRepository{collection: collection}
+}
+
+func (r *MongoAPIKeyRepository) Create(ctx context.Context, key models.APIKey) error {
+	_, err := r.collection.InsertOne(ctx, key)
+	return duplicateError(err)
+}
+
+func (r *MongoAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (r *MongoAPIKeyRepository) ListByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userId, "revoked_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *MongoAPIKeyRepository) Revoke(ctx context.Context, userId string, keyId string, at time.Time) error {
+	filter := bson.M{"_id": keyId, "user_id": userId, "revoked_at": nil}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *MongoAPIKeyRepository) RevokeAllByUser(ctx context.Context, userId string, at time.Time) error {
+	filter := bson.M{"user_id": userId, "revoked_at": nil}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+func (r *MongoAPIKeyRepository) UpdateLastUsed(ctx context.Context, keyId string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": keyId}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoInvitationRepository menyimpan undangan di collection MongoDB dengan invitation_id sebagai _id
+type MongoInvitationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoInvitationRepository(collection *mongo.Collection) *MongoInvitationRepository {
+	return &MongoInvitationRepository{collection: collection}
+}
+
+func (r *MongoInvitationRepository) Create(ctx context.Context, invitation models.Invitation) error {
+	_, err := r.collection.InsertOne(ctx, invitation)
+	return err
+}
+
+func (r *MongoInvitationRepository) FindByID(ctx context.Context, invitationId string) (models.Invitation, error) {
+	return r.findOne(ctx, bson.M{"_id": invitationId})
+}
+
+func (r *MongoInvitationRepository) FindPending(ctx context.Context, tokenHash string, now time.Time) (models.Invitation, error) {
+	filter := invitationStatusFilter(models.InvitationPending, now)
+	filter["token_hash"] = tokenHash
+	return r.findOne(ctx, filter)
+}
+
+func (r *MongoInvitationRepository) List(ctx context.Context, status string, now time.Time, offset int, limit int) ([]models.Invitation, int64, error) {
+	filter := invitationStatusFilter(status, now)
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	invitations := []models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, 0, err
+	}
+	return invitations, total, nil
+}
+
+func (r *MongoInvitationRepository) Accept(ctx context.Context, tokenHash string, userId string, now time.Time) (models.Invitation, error) {
+	filter := invitationStatusFilter(models.InvitationPending, now)
+	filter["token_hash"] = tokenHash
+	update := bson.M{"$set": bson.M{"accepted_at": now, "accepted_by": userId}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var invitation models.Invitation
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	return invitation, err
+}
+
+func (r *MongoInvitationRepository) Reopen(ctx context.Context, invitationId string) error {
+	update := bson.M{"$set": bson.M{"accepted_at": nil, "accepted_by": ""}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": invitationId, "accepted_at": bson.M{"$ne": nil}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *MongoInvitationRepository) Revoke(ctx context.Context, invitationId string, at time.Time) error {
+	filter := bson.M{"_id": invitationId, "accepted_at": nil, "revoked_at": nil}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *MongoInvitationRepository) findOne(ctx context.Context, filter bson.M) (models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.collection.FindOne(ctx, filter).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		return models.Invitation{}, ErrInvitationNotFound
+	}
+	return invitation, err
+}
+
+// invitationStatusFilter menerjemahkan Invitation.StatusAt ke filter MongoDB; status kosong berarti semua
+func invitationStatusFilter(status string, now time.Time) bson.M {
+	switch status {
+	case models.InvitationAccepted:
+		return bson.M{"accepted_at": bson.M{"$ne": nil}}
+	case models.InvitationRevoked:
+		return bson.M{"accepted_at": nil, "revoked_at": bson.M{"$ne": nil}}
+	case models.InvitationExpired:
+		return bson.M{"accepted_at": nil, "revoked_at": nil, "expires_at": bson.M{"$lte": now}}
+	case models.InvitationPending:
+		return bson.M{"accepted_at": nil, "revoked_at": nil, "expires_at": bson.M{"$gt": now}}
+	}
+	return bson.M{}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoOrganizationRepository menyimpan organisasi di collection MongoDB dengan org_id sebagai _id
+type MongoOrganizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOrganizationRepository(collection *mongo.Collection) *MongoOrganizationRepository {
+	return &MongoOrganizationRepository{collection: collection}
+}
+
+func (r *MongoOrganizationRepository) Create(ctx context.Context, org models.Organization) error {
+	_, err := r.collection.InsertOne(ctx, org)
+	return err
+}
+
+func (r *MongoOrganizationRepository) FindByID(ctx context.Context, orgId string) (models.Organization, error) {
+	var org models.Organization
+	err := r.collection.FindOne(ctx, bson.M{"_id": orgId}).Decode(&org)
+	if err == mongo.ErrNoDocuments {
+		return models.Organization{}, ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+func (r *MongoOrganizationRepository) FindByIDs(ctx context.Context, orgIds []string) ([]models.Organization, error) {
+	orgs := []models.Organization{}
+	if len(orgIds) == 0 {
+		return orgs, nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": orgIds}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
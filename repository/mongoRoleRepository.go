@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRoleRepository menyimpan role di collection MongoDB dengan nama role sebagai _id
+type MongoRoleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRoleRepository(collection *mongo.Collection) *MongoRoleRepository {
+	return &MongoRoleRepository{collection: collection}
+}
+
+func (r *MongoRoleRepository) FindByName(ctx context.Context, name string) (models.Role, error) {
+	var role models.Role
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&role)
+	if err == mongo.ErrNoDocuments {
+		return models.Role{}, ErrRoleNotFound
+	}
+	return role, err
+}
+
+func (r *MongoRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	roles := []models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *MongoRoleRepository) Create(ctx context.Context, role models.Role) error {
+	_, err := r.collection.InsertOne(ctx, role)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRoleExists
+	}
+	return err
+}
+
+func (r *MongoRoleRepository) Update(ctx context.Context, role models.Role) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": role.Name}, role)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+func (r *MongoRoleRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSessionRepository menyimpan sesi di collection MongoDB dengan TTL index pada expires_at
+type MongoSessionRepository struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+func NewMongoSessionRepository(collection *mongo.Collection) *MongoSessionRepository {
+	return &MongoSessionRepository{collection: collection}
+}
+
+func (r *MongoSessionRepository) Create(ctx context.Context, session models.Session) error {
+	if err := r.ensureIndexes(ctx); err != nil {
+		return err
+	}
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionRepository) FindByID(ctx context.Context, sessionId string) (models.Session, error) {
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"session_id": sessionId}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return models.Session{}, ErrSessionNotFound
+	}
+	return session, err
+}
+
+func (r *MongoSessionRepository) UpdateLastSeen(ctx context.Context, sessionId string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"session_id": sessionId}, bson.M{"$set": bson.M{"last_seen_at": at}})
+	return err
+}
+
+func (r *MongoSessionRepository) ReplaceRefreshToken(ctx context.Context, sessionId string, oldHash string, newHash string, at time.Time, expiresAt time.Time) (bool, error) {
+	filter := bson.M{"session_id": sessionId, "refresh_token_hash": oldHash}
+	update := bson.M{"$set": bson.M{
+		"refresh_token_hash": newHash,
+		"last_seen_at":       at,
+		"expires_at":         expiresAt,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSessionRepository) ListByUser(ctx context.Context, userId string, now time.Time) ([]models.Session, error) {
+	filter := bson.M{"user_id": userId, "expires_at": bson.M{"$gt": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *MongoSessionRepository) Delete(ctx context.Context, userId string, sessionId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userId, "session_id": sessionId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *MongoSessionRepository) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userId})
+	return err
+}
+
+// ensureIndexes membuat index session_id, user_id dan TTL expires_at saat sesi pertama disimpan
+func (r *MongoSessionRepository) ensureIndexes(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.indexed {
+		return nil
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "session_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+	r.indexed = true
+	return nil
+}
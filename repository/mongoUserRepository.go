@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserRepository menyimpan user di collection MongoDB. Collection organisasi hanya dipakai
+// untuk menyerialkan perubahan keanggotaan per organisasi (lihat changeMembership).
+type MongoUserRepository struct {
+	collection    *mongo.Collection
+	organizations *mongo.Collection
+}
+
+func NewMongoUserRepository(collection *mongo.Collection, organizations *mongo.Collection) *MongoUserRepository {
+	return &MongoUserRepository{collection: collection, organizations: organizations}
+}
+
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email, "deleted_at": nil})
+}
+
+func (r *MongoUserRepository) FindByPhone(ctx context.Context, phone string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"phone": phone, "deleted_at": nil})
+}
+
+func (r *MongoUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"user_id": userId, "deleted_at": nil})
+}
+
+func (r *MongoUserRepository) Create(ctx context.Context, user models.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	return duplicateError(err)
+}
+
+func (r *MongoUserRepository) Update(ctx context.Context, user models.User) error {
+	raw, err := bson.Marshal(user)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	// field yang punya operasi atomik sendiri tidak boleh ditimpa salinan lama milik pemanggil
+	for _, field := range []string{"_id", "memberships", "deleted_at", "version"} {
+		delete(fields, field)
+	}
+
+	filter := scope(ctx, bson.M{"user_id": userKey(user), "deleted_at": nil, "version": user.Version})
+	update := bson.M{"$set": fields, "$inc": bson.M{"version": 1}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return duplicateError(err)
+	}
+	if result.MatchedCount == 0 {
+		// bedakan user yang tidak ada dengan user yang sudah diubah request lain
+		delete(filter, "version")
+		count, err := r.collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+		return ErrUserConflict
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) List(ctx context.Context, offset int, limit int) ([]models.User, int64, error) {
+	// deleted_at: null juga cocok dengan dokumen lama yang belum punya field deleted_at
+	filter := scope(ctx, bson.M{"deleted_at": nil})
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *MongoUserRepository) Delete(ctx context.Context, userId string) error {
+	result, err := r.collection.DeleteOne(ctx, scope(ctx, bson.M{"user_id": userId}))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) SoftDelete(ctx context.Context, userId string, at time.Time) error {
+	update := bson.M{"$set": bson.M{"deleted_at": at, "updated_at": at}}
+	result, err := r.collection.UpdateOne(ctx, scope(ctx, bson.M{"user_id": userId, "deleted_at": nil}), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) Restore(ctx context.Context, userId string, deletedAfter time.Time) error {
+	filter := scope(ctx, bson.M{"user_id": userId, "deleted_at": bson.M{"$gte": deletedAfter}})
+	update := bson.M{"$set": bson.M{"deleted_at": nil, "updated_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, scope(ctx, bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, filter bson.M) (models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, scope(ctx, filter)).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *MongoUserRepository) AddMembership(ctx context.Context, userId string, membership models.Membership) error {
+	// calon anggota belum ada di tenant, jadi yang dibatasi adalah organisasi tujuannya
+	if !inOrgTenant(ctx, membership.Org_id) {
+		return ErrUserNotFound
+	}
+	// filter $ne membuat penambahan atomik: dokumen tidak cocok jika user sudah anggota
+	filter := bson.M{"user_id": userId, "deleted_at": nil, "memberships.org_id": bson.M{"$ne": membership.Org_id}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"memberships": membership}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userId, "deleted_at": nil})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+		return ErrMembershipExists
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) SetMembershipRole(ctx context.Context, userId string, orgId string, role string) error {
+	filter := bson.M{"user_id": userId, "deleted_at": nil, "memberships.org_id": orgId}
+	return r.changeMembership(ctx, filter, orgId, role != models.RoleOrgAdmin, bson.M{"$set": bson.M{"memberships.$.role": role}})
+}
+
+func (r *MongoUserRepository) RemoveMembership(ctx context.Context, userId string, orgId string) error {
+	filter := bson.M{"user_id": userId, "memberships.org_id": orgId}
+	return r.changeMembership(ctx, filter, orgId, true, bson.M{"$pull": bson.M{"memberships": bson.M{"org_id": orgId}}})
+}
+
+// changeMembership menerapkan update pada keanggotaan user di filter. Jika dropsAdmin dan user adalah ORG_ADMIN,
+// update hanya dijalankan bila masih ada ORG_ADMIN lain. Pengecekan dan update berada dalam satu transaksi
+// yang juga menulis dokumen organisasi, sehingga dua perubahan bersamaan di organisasi yang sama saling konflik
+// dan salah satunya diulang; tanpa itu keduanya bisa melihat dua admin lalu sama-sama menurunkan admin.
+func (r *MongoUserRepository) changeMembership(ctx context.Context, filter bson.M, orgId string, dropsAdmin bool, update bson.M) error {
+	if !inOrgTenant(ctx, orgId) {
+		return ErrUserNotFound
+	}
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := r.organizations.UpdateOne(sc, bson.M{"_id": orgId}, bson.M{"$inc": bson.M{"membership_changes": 1}}); err != nil {
+			return nil, err
+		}
+		if dropsAdmin {
+			var user models.User
+			err := r.collection.FindOne(sc, filter).Decode(&user)
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrUserNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			// user yang sudah dihapus tidak dihitung sebagai admin, jadi boleh dikeluarkan
+			if membership := user.Memberships[membershipIndex(user, orgId)]; membership.Role == models.RoleOrgAdmin && user.Deleted_at == nil {
+				admins, err := r.CountMembers(sc, orgId, models.RoleOrgAdmin)
+				if err != nil {
+					return nil, err
+				}
+				if admins <= 1 {
+					return nil, ErrLastOrgAdmin
+				}
+			}
+		}
+		result, err := r.collection.UpdateOne(sc, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, ErrUserNotFound
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (r *MongoUserRepository) CountMembers(ctx context.Context, orgId string, role string) (int64, error) {
+	if !inOrgTenant(ctx, orgId) {
+		return 0, nil
+	}
+	filter := bson.M{"deleted_at": nil, "memberships": bson.M{"$elemMatch": bson.M{"org_id": orgId, "role": role}}}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// scope menambahkan syarat keanggotaan organisasi jika ctx dibuat dengan WithTenant
+func scope(ctx context.Context, filter bson.M) bson.M {
+	if orgId := TenantFrom(ctx); orgId != "" {
+		filter["memberships.org_id"] = orgId
+	}
+	return filter
+}
+
+// dupKeyIndex mengambil nama field dari pesan E11000, mis. "index: email_1 dup key: { email: ... }"
+var dupKeyIndex = regexp.MustCompile(`index: (\w+?)_1 dup key`)
+
+// duplicateError mengubah error duplicate key dari unique index menjadi *DuplicateError
+func duplicateError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	field := "user"
+	var writeException mongo.WriteException
+	if errors.As(err, &writeException) {
+		for _, writeError := range writeException.WriteErrors {
+			// MongoDB 4.4+ menyertakan keyPattern, versi lama hanya pesan teks
+			if keyPattern, ok := writeError.Raw.Lookup("keyPattern").DocumentOK(); ok {
+				if elements, err := keyPattern.Elements(); err == nil && len(elements) > 0 {
+					field = elements[0].Key()
+					break
+				}
+			}
+			if match := dupKeyIndex.FindStringSubmatch(writeError.Message); match != nil {
+				field = match[1]
+				break
+			}
+		}
+	}
+	return &DuplicateError{Field: field}
+}
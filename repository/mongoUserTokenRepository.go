@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golangsidang/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserTokenRepository menyimpan token sekali pakai di collection MongoDB dengan TTL index pada expires_at
+type MongoUserTokenRepository struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+func NewMongoUserTokenRepository(collection *mongo.Collection) *MongoUserTokenRepository {
+	return &MongoUserTokenRepository{collection: collection}
+}
+
+func (r *MongoUserTokenRepository) Create(ctx context.Context, token models.UserToken) error {
+	if err := r.ensureIndexes(ctx); err != nil {
+		return err
+	}
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *MongoUserTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (models.UserToken, error) {
+	filter := bson.M{"_id": tokenHash, "purpose": purpose, "expires_at": bson.M{"$gt": now}}
+	var token models.UserToken
+	err := r.collection.FindOneAndDelete(ctx, filter).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return models.UserToken{}, ErrUserTokenNotFound
+	}
+	return token, err
+}
+
+func (r *MongoUserTokenRepository) FindLatest(ctx context.Context, userId string, purpose string) (models.UserToken, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	var token models.UserToken
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userId, "purpose": purpose}, opts).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return models.UserToken{}, ErrUserTokenNotFound
+	}
+	return token, err
+}
+
+func (r *MongoUserTokenRepository) AddFailedAttempt(ctx context.Context, userId string, purpose string) (int, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var token models.UserToken
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": userId, "purpose": purpose}, bson.M{"$inc": bson.M{"attempts": 1}}, opts).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return 0, ErrUserTokenNotFound
+	}
+	return token.Attempts, err
+}
+
+func (r *MongoUserTokenRepository) DeleteByUser(ctx context.Context, userId string, purpose string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userId, "purpose": purpose})
+	return err
+}
+
+// ensureIndexes membuat index user_id dan TTL expires_at saat token pertama disimpan
+func (r *MongoUserTokenRepository) ensureIndexes(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.indexed {
+		return nil
+	}
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+	r.indexed = true
+	return nil
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+)
+
+// ErrOrganizationNotFound dikembalikan ketika organisasi tidak ada
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// OrganizationRepository menyimpan organisasi (tenant); anggotanya disimpan di User.Memberships
+type OrganizationRepository interface {
+	Create(ctx context.Context, org models.Organization) error
+	FindByID(ctx context.Context, orgId string) (models.Organization, error)
+	// FindByIDs mengembalikan organisasi yang ada dari orgIds, urut nama
+	FindByIDs(ctx context.Context, orgIds []string) ([]models.Organization, error)
+}
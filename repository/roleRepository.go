@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+)
+
+// RoleRepository menyimpan role dan permission-nya
+type RoleRepository interface {
+	FindByName(ctx context.Context, name string) (models.Role, error)
+	// List mengembalikan semua role urut nama
+	List(ctx context.Context) ([]models.Role, error)
+	Create(ctx context.Context, role models.Role) error
+	// Update mengganti seluruh role berdasarkan Name
+	Update(ctx context.Context, role models.Role) error
+	Delete(ctx context.Context, name string) error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"time"
+)
+
+// ErrSessionNotFound dikembalikan ketika sesi tidak ada (sudah diakhiri atau bukan milik user)
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionRepository menyimpan sesi login per perangkat
+type SessionRepository interface {
+	Create(ctx context.Context, session models.Session) error
+	FindByID(ctx context.Context, sessionId string) (models.Session, error)
+	UpdateLastSeen(ctx context.Context, sessionId string, at time.Time) error
+	// ReplaceRefreshToken mengganti hash refresh token hanya jika oldHash masih yang tersimpan;
+	// false berarti refresh token lama sudah pernah dirotasi
+	ReplaceRefreshToken(ctx context.Context, sessionId string, oldHash string, newHash string, at time.Time, expiresAt time.Time) (bool, error)
+	// ListByUser mengembalikan sesi yang belum kedaluwarsa pada waktu now, yang terakhir dipakai lebih dulu
+	ListByUser(ctx context.Context, userId string, now time.Time) ([]models.Session, error)
+	Delete(ctx context.Context, userId string, sessionId string) error
+	DeleteByUser(ctx context.Context, userId string) error
+}
@@ -0,0 +1,29 @@
+package repository
+
+import "context"
+
+type tenantKey struct{}
+
+// WithTenant membatasi semua query UserRepository yang memakai ctx ini ke anggota organisasi orgId
+func WithTenant(ctx context.Context, orgId string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, orgId)
+}
+
+// TenantFrom mengembalikan organisasi dari WithTenant, string kosong jika query tidak dibatasi
+func TenantFrom(ctx context.Context) string {
+	orgId, _ := ctx.Value(tenantKey{}).(string)
+	return orgId
+}
+
+// WithoutTenant membuat ctx yang sengaja tidak dibatasi organisasi, untuk query yang memang harus
+// melihat user di luar tenant (mis. mencari calon anggota berdasarkan email)
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, "")
+}
+
+// inOrgTenant bernilai false jika ctx dibatasi ke organisasi selain orgId. Method keanggotaan
+// memperlakukan organisasi lain seperti user yang tidak ditemukan.
+func inOrgTenant(ctx context.Context, orgId string) bool {
+	tenant := TenantFrom(ctx)
+	return tenant == "" || tenant == orgId
+}
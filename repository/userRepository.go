@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"time"
+)
+
+var (
+	// ErrUserNotFound dikembalikan ketika user tidak ada
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists dikembalikan ketika field unik (email, phone atau user_id) sudah dipakai;
+	// error sebenarnya bertipe *DuplicateError yang menyebut field-nya
+	ErrUserExists = errors.New("user already exists")
+	// ErrUserConflict dikembalikan Update ketika user sudah diubah request lain sejak dibaca
+	ErrUserConflict = errors.New("user was modified by another request, try again")
+	// ErrMembershipExists dikembalikan ketika user sudah menjadi anggota organisasi
+	ErrMembershipExists = errors.New("user is already a member of the organization")
+	// ErrLastOrgAdmin dikembalikan ketika perubahan keanggotaan akan membuat organisasi tanpa ORG_ADMIN
+	ErrLastOrgAdmin = errors.New("organization must keep at least one " + models.RoleOrgAdmin)
+)
+
+// DuplicateError dikembalikan Create dan Update ketika field unik sudah dipakai user lain
+type DuplicateError struct {
+	Field string
+}
+
+func (e *DuplicateError) Error() string {
+	return e.Field + " already exists"
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrUserExists
+}
+
+// UserRepository adalah penyimpanan user yang dipakai controllers.
+// Ada implementasi MongoDB dan implementasi di memori (untuk development dan pengujian tanpa MongoDB).
+// User yang sudah di-soft-delete tidak dikembalikan oleh Find*, List maupun diubah oleh Update.
+// Jika ctx dibuat dengan WithTenant, semua method selain Create hanya melihat anggota organisasi tersebut,
+// dan *Membership/CountMembers hanya boleh mengubah atau menghitung organisasi itu sendiri.
+// Query yang memang harus lintas tenant memakai WithoutTenant.
+type UserRepository interface {
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByPhone(ctx context.Context, phone string) (models.User, error)
+	FindByID(ctx context.Context, userId string) (models.User, error)
+	Create(ctx context.Context, user models.User) error
+	// Update menyimpan field user berdasarkan User_id jika Version masih sama dengan yang tersimpan,
+	// lalu menaikkan Version; ErrUserConflict jika tidak. Memberships dan Deleted_at tidak ikut diubah,
+	// keduanya hanya diubah lewat *Membership, SoftDelete dan Restore.
+	Update(ctx context.Context, user models.User) error
+	// List mengembalikan user mulai dari offset sebanyak limit, beserta jumlah seluruh user
+	List(ctx context.Context, offset int, limit int) ([]models.User, int64, error)
+	Delete(ctx context.Context, userId string) error
+	// SoftDelete menandai user terhapus pada waktu at
+	SoftDelete(ctx context.Context, userId string, at time.Time) error
+	// Restore membatalkan soft delete jika user dihapus setelah deletedAfter
+	Restore(ctx context.Context, userId string, deletedAfter time.Time) error
+	// PurgeDeleted menghapus permanen user yang di-soft-delete sebelum deletedBefore
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// AddMembership menambahkan user ke organisasi; ErrMembershipExists jika sudah menjadi anggota
+	AddMembership(ctx context.Context, userId string, membership models.Membership) error
+	// SetMembershipRole mengganti role user di organisasi; ErrUserNotFound jika bukan anggota,
+	// ErrLastOrgAdmin jika user adalah ORG_ADMIN terakhir dan role barunya bukan ORG_ADMIN
+	SetMembershipRole(ctx context.Context, userId string, orgId string, role string) error
+	// RemoveMembership mengeluarkan user dari organisasi; ErrUserNotFound jika bukan anggota,
+	// ErrLastOrgAdmin jika user adalah ORG_ADMIN terakhir
+	RemoveMembership(ctx context.Context, userId string, orgId string) error
+	// CountMembers menghitung anggota organisasi yang memiliki role tersebut
+	CountMembers(ctx context.Context, orgId string, role string) (int64, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"golangsidang/models"
+	"time"
+)
+
+// ErrUserTokenNotFound dikembalikan ketika token tidak ada, sudah dipakai atau kedaluwarsa
+var ErrUserTokenNotFound = errors.New("token not found")
+
+// UserTokenRepository menyimpan token sekali pakai milik user
+type UserTokenRepository interface {
+	Create(ctx context.Context, token models.UserToken) error
+	// Consume mengambil sekaligus menghapus token yang belum kedaluwarsa pada waktu now,
+	// sehingga token yang sama tidak bisa dipakai dua kali
+	Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (models.UserToken, error)
+	// FindLatest mengembalikan token terbaru milik user untuk purpose tersebut
+	FindLatest(ctx context.Context, userId string, purpose string) (models.UserToken, error)
+	// AddFailedAttempt menambah jumlah percobaan pada token user untuk purpose tersebut
+	// dan mengembalikan jumlah terbarunya
+	AddFailedAttempt(ctx context.Context, userId string, purpose string) (int, error)
+	// DeleteByUser menghapus semua token user untuk purpose tersebut
+	DeleteByUser(ctx context.Context, userId string, purpose string) error
+}
@@ -0,0 +1,71 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore adalah Store di memori, cocok untuk satu instance atau untuk development
+type MemoryStore struct {
+	mu    sync.Mutex
+	jtis  map[string]time.Time
+	users map[string]entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jtis: map[string]time.Time{}, users: map[string]entry{}}
+}
+
+func (s *MemoryStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.jtis[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.jtis[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryStore) RevokeUser(ctx context.Context, uid string, before time.Time, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.users[uid] = entry{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) RevokedBefore(ctx context.Context, uid string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.users[uid]
+	if !ok || time.Now().After(e.expiresAt) {
+		return time.Time{}, nil
+	}
+	return e.before, nil
+}
+
+// prune membuang entry yang sudah lewat TTL, dipanggil dengan lock dipegang
+func (s *MemoryStore) prune() {
+	now := time.Now()
+	for jti, expiresAt := range s.jtis {
+		if now.After(expiresAt) {
+			delete(s.jtis, jti)
+		}
+	}
+	for uid, e := range s.users {
+		if now.After(e.expiresAt) {
+			delete(s.users, uid)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revokedToken adalah dokumen deny-list. Kind "jti" untuk satu token, "user" untuk semua token milik user.
+type revokedToken struct {
+	Key        string    `bson:"_id"`
+	Kind       string    `bson:"kind"`
+	Before     time.Time `bson:"before,omitempty"`
+	Expires_at time.Time `bson:"expires_at"`
+}
+
+// MongoStore adalah Store di MongoDB. Dokumen dihapus otomatis oleh TTL index pada expires_at.
+type MongoStore struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (s *MongoStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.upsert(ctx, revokedToken{Key: "jti:" + jti, Kind: "jti", Expires_at: expiresAt})
+}
+
+func (s *MongoStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var doc revokedToken
+	err := s.collection.FindOne(ctx, bson.M{"_id": "jti:" + jti}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// TTL monitor MongoDB berjalan per menit, jadi kedaluwarsa tetap dicek di sini
+	return time.Now().Before(doc.Expires_at), nil
+}
+
+func (s *MongoStore) RevokeUser(ctx context.Context, uid string, before time.Time, expiresAt time.Time) error {
+	return s.upsert(ctx, revokedToken{Key: "user:" + uid, Kind: "user", Before: before, Expires_at: expiresAt})
+}
+
+func (s *MongoStore) RevokedBefore(ctx context.Context, uid string) (time.Time, error) {
+	var doc revokedToken
+	err := s.collection.FindOne(ctx, bson.M{"_id": "user:" + uid}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if time.Now().After(doc.Expires_at) {
+		return time.Time{}, nil
+	}
+	return doc.Before, nil
+}
+
+func (s *MongoStore) upsert(ctx context.Context, doc revokedToken) error {
+	if err := s.ensureIndex(ctx); err != nil {
+		return err
+	}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": doc.Key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ensureIndex membuat TTL index saat pertama kali ada token yang dicabut
+func (s *MongoStore) ensureIndex(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.indexed {
+		return nil
+	}
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+	s.indexed = true
+	return nil
+}
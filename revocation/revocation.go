@@ -0,0 +1,38 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store menyimpan daftar token yang dicabut sebelum kedaluwarsa.
+// Token dicabut satu per satu lewat jti, atau semua token milik user lewat batas waktu terbit.
+type Store interface {
+	// Revoke mencabut token dengan jti tertentu sampai expiresAt (setelah itu token sudah tidak berlaku)
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked mengecek apakah jti ada di deny-list
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeUser mencabut semua token milik uid yang terbit sebelum detik before, disimpan sampai expiresAt
+	RevokeUser(ctx context.Context, uid string, before time.Time, expiresAt time.Time) error
+	// RevokedBefore mengembalikan batas waktu terbit token milik uid; zero time jika tidak ada
+	RevokedBefore(ctx context.Context, uid string) (time.Time, error)
+}
+
+// IsTokenRevoked menggabungkan pengecekan jti dan pencabutan per user
+func IsTokenRevoked(ctx context.Context, store Store, jti string, uid string, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		revoked, err := store.IsRevoked(ctx, jti)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	before, err := store.RevokedBefore(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	// iat hanya berpresisi detik, jadi batasnya dibulatkan ke detik: token yang terbit di detik yang sama
+	// dengan pencabutan (mis. login ulang setelah ganti password) tetap diterima. Token lama di detik itu
+	// tetap tertolak karena sesinya ikut diakhiri.
+	before = before.Truncate(time.Second)
+	return !before.IsZero() && issuedAt.Before(before), nil
+}
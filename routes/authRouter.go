@@ -1,12 +1,25 @@
 package routes
 
 import (
+	"golangsidang/config"
 	controller "golangsidang/controllers"
+	helper "golangsidang/helpers"
+	"golangsidang/mailer"
+	"golangsidang/middleware"
+	"golangsidang/ratelimit"
+	"golangsidang/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(incomingRoutes *gin.Engine) { // membuat routes auth
-	incomingRoutes.POST("user/signup", controller.Signup()) // membuat routes signup untuk mengani sigup
-	incomingRoutes.POST("user/login", controller.Login())   // membuat routes signin untuk mengani sigin
+func AuthRoutes(incomingRoutes *gin.Engine, users repository.UserRepository, services *helper.Services, mail mailer.Mailer, limiter *middleware.RateLimiter, cfg config.Config) { // membuat routes auth
+	incomingRoutes.POST("user/signup", limiter.Limit(ratelimit.PolicySignup, middleware.KeyByIP), controller.Signup(users, services, mail, cfg.VerificationURL(), cfg.InviteOnly)) // membuat routes signup untuk mengani sigup
+	incomingRoutes.POST("user/login", limiter.Limit(ratelimit.PolicyLogin, middleware.KeyByIP), controller.Login(users, services, cfg.RequireVerifiedEmail))                       // membuat routes signin untuk mengani sigin
+	incomingRoutes.POST("user/login/mfa", limiter.Limit(ratelimit.PolicyLogin, middleware.KeyByIP), controller.LoginMFA(users, services))                                          // langkah kedua login jika TOTP aktif
+	incomingRoutes.POST("user/refresh", limiter.Limit(ratelimit.PolicyRefresh, middleware.KeyByIP), controller.Refresh(users, services))                                           // menukar refresh token dengan token baru
+	incomingRoutes.GET("keys/paseto", controller.GetPasetoPublicKeys(services))                                                                                                    // public key untuk verifikasi PASETO v2.public
+	incomingRoutes.POST("user/password/forgot", limiter.Limit(ratelimit.PolicyForgotPassword, middleware.KeyByIP), controller.ForgotPassword(users, services, mail, cfg.PasswordResetURL))
+	incomingRoutes.POST("user/password/reset", limiter.Limit(ratelimit.PolicyResetPassword, middleware.KeyByIP), controller.ResetPassword(users, services))
+	incomingRoutes.GET("user/verify", controller.VerifyEmail(users, services)) // konfirmasi email dari link verifikasi
+	incomingRoutes.POST("user/verify/resend", limiter.Limit(ratelimit.PolicyResendVerification, middleware.KeyByIP), controller.ResendVerification(users, services, mail, cfg.VerificationURL()))
 }
@@ -1,14 +1,60 @@
 package routes
 
 import (
+	"golangsidang/config"
 	controller "golangsidang/controllers"
+	helper "golangsidang/helpers"
+	"golangsidang/mailer"
 	"golangsidang/middleware"
+	"golangsidang/models"
+	"golangsidang/phone"
+	"golangsidang/ratelimit"
+	"golangsidang/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) { // membuat routes auth
-	incomingRoutes.Use(middleware.Authenticate())       // menggunakan middleware authenticate
-	incomingRoutes.GET("/users", controller.GetUsers()) // membuat routes user untuk mengani user
-	incomingRoutes.GET("/user/:user_id", controller.GetUser())
+func UserRoutes(incomingRoutes *gin.Engine, users repository.UserRepository, services *helper.Services, authenticate gin.HandlerFunc, sms phone.SMSSender, mail mailer.Mailer, limiter *middleware.RateLimiter, cfg config.Config) { // membuat routes auth
+	// route akun sendiri yang tidak boleh diakses dengan API key
+	session := middleware.RequireSession()
+
+	// users:* pada route /users dan /user/:user_id adalah permission global (admin platform), sengaja tidak dibatasi tenant;
+	// admin organisasi mengelola anggotanya lewat group /org di bawah
+	incomingRoutes.Use(authenticate)                                                                                                                                       // menggunakan middleware authenticate
+	incomingRoutes.GET("/users", middleware.Require(models.PermissionUsersRead), limiter.Limit(ratelimit.PolicyUsers, middleware.KeyByAPIKey), controller.GetUsers(users)) // membuat routes user untuk mengani user
+	incomingRoutes.GET("/user/:user_id", middleware.RequireSelfOr("user_id", models.PermissionUsersRead), controller.GetUser(users))
+	incomingRoutes.PATCH("/user/:user_id", middleware.RequireSelfOr("user_id", models.PermissionUsersWrite), controller.UpdateUser(users, services)) // ubah profil, user_type butuh roles:assign
+	incomingRoutes.DELETE("/user/:user_id", middleware.RequireSelfOr("user_id", models.PermissionUsersDelete), controller.DeleteUser(users, services, cfg.DeletionGracePeriod))
+	incomingRoutes.POST("/user/:user_id/restore", middleware.Require(models.PermissionUsersDelete), controller.RestoreUser(users, cfg.DeletionGracePeriod)) // pulihkan akun terhapus
+	incomingRoutes.POST("/keys/rotate", middleware.Require(models.PermissionKeysRotate), controller.RotateSigningKey(services))                             // rotasi kunci JWT
+	incomingRoutes.POST("/user/logout", session, controller.Logout(services))
+	incomingRoutes.POST("/user/password", session, controller.ChangePassword(users, services))     // ganti password, sesi lain diakhiri
+	incomingRoutes.POST("/user/phone/otp", session, controller.SendPhoneOTP(users, services, sms)) // kirim kode OTP ke nomor user
+	incomingRoutes.POST("/user/phone/verify", session, controller.VerifyPhone(users, services))
+	incomingRoutes.POST("/user/mfa/totp/enroll", session, controller.EnrollTOTP(users))
+	incomingRoutes.POST("/user/mfa/totp/confirm", session, controller.ConfirmTOTP(users))
+	incomingRoutes.DELETE("/user/:user_id/mfa", middleware.Require(models.PermissionUsersSecurity), controller.ResetMFA(users, services)) // reset MFA user lain
+	incomingRoutes.GET("/user/sessions", session, controller.GetSessions(services))
+	incomingRoutes.DELETE("/user/sessions/:id", session, controller.DeleteSession(services))
+	incomingRoutes.POST("/user/:user_id/revoke", middleware.Require(models.PermissionUsersSecurity), controller.RevokeSessions(services))    // cabut semua sesi user
+	incomingRoutes.POST("/user/:user_id/unlock", middleware.Require(models.PermissionUsersSecurity), controller.UnlockUser(users, services)) // buka kunci login
+	incomingRoutes.GET("/roles", middleware.Require(models.PermissionRolesRead), controller.GetRoles(services))
+	incomingRoutes.POST("/roles", middleware.Require(models.PermissionRolesWrite), controller.CreateRole(services))
+	incomingRoutes.PUT("/roles/:name", middleware.Require(models.PermissionRolesWrite), controller.UpdateRole(services))
+	incomingRoutes.DELETE("/roles/:name", middleware.Require(models.PermissionRolesWrite), controller.DeleteRole(services))
+	incomingRoutes.GET("/invitations", middleware.Require(models.PermissionUsersInvite), controller.GetInvitations(services))
+	incomingRoutes.POST("/invitations", middleware.Require(models.PermissionUsersInvite), controller.CreateInvitation(users, services, mail, cfg.InvitationURL, cfg.InvitationTTL)) // undangan signup sekali pakai
+	incomingRoutes.DELETE("/invitations/:invitation_id", middleware.Require(models.PermissionUsersInvite), controller.RevokeInvitation(services))
+	incomingRoutes.GET("/user/apikeys", session, controller.GetAPIKeys(services))
+	incomingRoutes.POST("/user/apikeys", session, controller.CreateAPIKey(services)) // key lengkap hanya ditampilkan sekali
+	incomingRoutes.DELETE("/user/apikeys/:key_id", session, controller.RevokeAPIKey(services))
+	incomingRoutes.GET("/orgs", controller.GetOrganizations(users, services)) // organisasi milik user yang login
+	incomingRoutes.POST("/orgs", controller.CreateOrganization(users, services))
+	incomingRoutes.POST("/orgs/:org_id/switch", session, controller.SwitchOrganization(users, services)) // pindah organisasi aktif, token baru diterbitkan
+
+	org := incomingRoutes.Group("/org", middleware.Tenant()) // anggota organisasi aktif (claim tid)
+	org.GET("/members", middleware.RequireOrg(models.PermissionMembersRead), controller.GetMembers(users))
+	org.POST("/members", middleware.RequireOrg(models.PermissionMembersWrite), controller.AddMember(users))
+	org.PATCH("/members/:user_id", middleware.RequireOrg(models.PermissionMembersWrite), controller.UpdateMember(users, services))
+	org.DELETE("/members/:user_id", middleware.RequireOrg(models.PermissionMembersWrite), controller.RemoveMember(users, services))
 }
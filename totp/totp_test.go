@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// secret RFC 6238 lampiran B ("12345678901234567890") dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Kode SHA1 dari RFC 6238 lampiran B, dipotong menjadi Digits angka terakhir
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		step, ok := Validate(rfcSecret, v.code, at)
+		if !ok || step != Step(at) {
+			t.Errorf("Validate(%s, %d) = %d, %v; want %d, true", v.code, v.unix, step, ok, Step(at))
+		}
+	}
+
+	// kode dari langkah sebelumnya masih diterima dalam Skew, lebih jauh dari itu ditolak
+	at := time.Unix(1111111109, 0)
+	if step, ok := Validate(rfcSecret, "081804", at.Add(Period)); !ok || step != Step(at) {
+		t.Errorf("code from previous step: %d, %v", step, ok)
+	}
+	if _, ok := Validate(rfcSecret, "081804", at.Add(2*Period)); ok {
+		t.Error("code two steps old was accepted")
+	}
+	if _, ok := Validate(rfcSecret, "81804", at); ok {
+		t.Error("code with the wrong length was accepted")
+	}
+}